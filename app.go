@@ -0,0 +1,210 @@
+//go:build windows
+// +build windows
+
+package webview2
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jchv/go-webview2/internal/w32"
+)
+
+// QuitPolicy specifies when an App stops its main loop.
+type QuitPolicy int
+
+const (
+	// QuitOnLastWindowClosed stops the main loop once the last window of the
+	// app has been destroyed.
+	QuitOnLastWindowClosed QuitPolicy = iota
+
+	// QuitExplicitly keeps the main loop running without any windows, until
+	// Quit is called or the context passed to RunContext is done.
+	QuitExplicitly
+)
+
+// AppOptions customizes an App.
+type AppOptions struct {
+	// QuitPolicy specifies when Run returns.
+	QuitPolicy QuitPolicy
+}
+
+// App runs the main loop of the UI thread for any number of windows. Each
+// window has its own bindings and lifecycle hooks, and closing one does not
+// stop the loop unless the quit policy says so.
+//
+// NewApp must be called from the main goroutine, which this package locks to
+// the UI thread. Windows of an app must not be run with their own Run or
+// RunContext methods; their Terminate method stops the loop of the app.
+type App struct {
+	opts       AppOptions
+	mainthread uintptr
+
+	m         sync.Mutex
+	windows   []*webview
+	dispatchq []func()
+	runerr    error
+}
+
+// NewApp creates an App with no windows.
+func NewApp(opts AppOptions) *App {
+	a := &App{opts: opts}
+	a.mainthread, _, _ = w32.Kernel32GetCurrentThreadID.Call()
+	return a
+}
+
+// NewWindow creates a window of the app. It may be called from any goroutine,
+// but when called from outside the UI thread, it waits for the window to be
+// created by the main loop. It returns nil if the window cannot be created.
+func (a *App) NewWindow(options WebViewOptions) WebView {
+	if !a.onUIThread() {
+		done := make(chan WebView, 1)
+		a.Dispatch(func() {
+			done <- a.NewWindow(options)
+		})
+		return <-done
+	}
+
+	w := newWebview(options, a)
+	if w == nil {
+		return nil
+	}
+	a.m.Lock()
+	a.windows = append(a.windows, w)
+	pending := len(a.dispatchq) > 0
+	a.m.Unlock()
+	if pending {
+		// Creating the window runs a nested message loop, which drops
+		// messages posted to the thread.
+		a.post()
+	}
+	return w
+}
+
+// Windows returns the windows of the app that have not been destroyed, in
+// the order they were created.
+func (a *App) Windows() []WebView {
+	a.m.Lock()
+	defer a.m.Unlock()
+	windows := make([]WebView, len(a.windows))
+	for i, w := range a.windows {
+		windows[i] = w
+	}
+	return windows
+}
+
+// Run runs the main loop until the app quits.
+func (a *App) Run() {
+	_ = a.RunContext(context.Background())
+}
+
+// RunContext is like Run, but also stops the main loop when ctx is cancelled,
+// in which case it returns ctx.Err(). Like WebView.RunContext, it returns
+// ErrBrowserProcessExited if the browser process of any window exits
+// unexpectedly, or another error if the message loop fails.
+func (a *App) RunContext(ctx context.Context) error {
+	if err := runMessageLoop(ctx, a.Dispatch, a.runDispatched); err != nil {
+		return err
+	}
+	a.m.Lock()
+	runerr := a.runerr
+	a.runerr = nil
+	a.m.Unlock()
+	if runerr != nil {
+		return runerr
+	}
+	return ctx.Err()
+}
+
+// Quit stops the main loop, whatever the quit policy. Windows that are still
+// open are not destroyed. It is safe to call Quit from any goroutine.
+func (a *App) Quit() {
+	a.ui(func() {
+		_, _, _ = w32.User32PostQuitMessage.Call(0)
+	})
+}
+
+// Dispatch posts a function to be executed on the UI thread, whether or not
+// the app has any windows.
+func (a *App) Dispatch(f func()) {
+	a.m.Lock()
+	a.dispatchq = append(a.dispatchq, f)
+	a.m.Unlock()
+	a.post()
+}
+
+// post asks the UI thread to run the functions queued by Dispatch. Like
+// WebView.Dispatch, it posts to a window of the app, so that the message is
+// not dropped by modal loops, and only posts to the thread when the app has
+// no window.
+func (a *App) post() {
+	a.m.Lock()
+	var hwnd uintptr
+	for _, w := range a.windows {
+		if w.hwnd != 0 {
+			hwnd = w.hwnd
+			break
+		}
+	}
+	a.m.Unlock()
+	if hwnd != 0 {
+		if r, _, _ := w32.User32PostMessageW.Call(hwnd, w32.WMApp, 0, 0); r != 0 {
+			return
+		}
+	}
+	_, _, _ = w32.User32PostThreadMessageW.Call(a.mainthread, w32.WMApp, 0, 0)
+}
+
+// runDispatched runs the functions queued by Dispatch, along with those that
+// windows of the app queued while they had no window to post to.
+func (a *App) runDispatched() {
+	a.m.Lock()
+	q := append([]func(){}, a.dispatchq...)
+	a.dispatchq = []func(){}
+	windows := append([]*webview{}, a.windows...)
+	a.m.Unlock()
+	for _, f := range q {
+		f()
+	}
+	for _, w := range windows {
+		w.runDispatched()
+	}
+}
+
+func (a *App) onUIThread() bool {
+	thread, _, _ := w32.Kernel32GetCurrentThreadID.Call()
+	return thread == a.mainthread
+}
+
+func (a *App) ui(f func()) {
+	if a.onUIThread() {
+		f()
+		return
+	}
+	a.Dispatch(f)
+}
+
+// windowDestroyed forgets w, which has been destroyed, and quits if it was
+// the last window and the quit policy says so. It runs on the UI thread.
+func (a *App) windowDestroyed(w *webview) {
+	a.m.Lock()
+	for i, v := range a.windows {
+		if v == w {
+			a.windows = append(a.windows[:i], a.windows[i+1:]...)
+			break
+		}
+	}
+	last := len(a.windows) == 0
+	a.m.Unlock()
+	if last && a.opts.QuitPolicy == QuitOnLastWindowClosed {
+		_, _, _ = w32.User32PostQuitMessage.Call(0)
+	}
+}
+
+// fail stops the main loop with err. It runs on the UI thread.
+func (a *App) fail(err error) {
+	a.m.Lock()
+	a.runerr = err
+	a.m.Unlock()
+	_, _, _ = w32.User32PostQuitMessage.Call(0)
+}
@@ -0,0 +1,127 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Binder is implemented by WebView and Replayer.
+type Binder interface {
+	BindWithOptions(name string, f interface{}, opts BindOptions) error
+}
+
+// BindFunc0 binds f under name, like Bind, for a function that takes no
+// arguments. Unlike with Bind, mistakes in the signature of f are compile
+// errors, and calls to f do not use reflection. If opts is given, it is used
+// as with BindWithOptions.
+func BindFunc0[R any](b Binder, name string, f func() (R, error), opts ...BindOptions) error {
+	return bindTyped(b, name, f, decoders(), func(ctx context.Context, args []interface{}) (interface{}, error) {
+		return f()
+	}, opts)
+}
+
+// BindFunc binds f under name, like Bind, for a function that takes one
+// argument. Unlike with Bind, mistakes in the signature of f are compile
+// errors, and calls to f do not use reflection. If opts is given, it is used
+// as with BindWithOptions.
+func BindFunc[A, R any](b Binder, name string, f func(A) (R, error), opts ...BindOptions) error {
+	return bindTyped(b, name, f, decoders(decodeArg[A]), func(ctx context.Context, args []interface{}) (interface{}, error) {
+		return f(argOf[A](args[0]))
+	}, opts)
+}
+
+// BindFunc2 is BindFunc for a function that takes two arguments.
+func BindFunc2[A, B, R any](b Binder, name string, f func(A, B) (R, error), opts ...BindOptions) error {
+	return bindTyped(b, name, f, decoders(decodeArg[A], decodeArg[B]), func(ctx context.Context, args []interface{}) (interface{}, error) {
+		return f(argOf[A](args[0]), argOf[B](args[1]))
+	}, opts)
+}
+
+// BindFunc3 is BindFunc for a function that takes three arguments.
+func BindFunc3[A, B, C, R any](b Binder, name string, f func(A, B, C) (R, error), opts ...BindOptions) error {
+	return bindTyped(b, name, f, decoders(decodeArg[A], decodeArg[B], decodeArg[C]), func(ctx context.Context, args []interface{}) (interface{}, error) {
+		return f(argOf[A](args[0]), argOf[B](args[1]), argOf[C](args[2]))
+	}, opts)
+}
+
+// BindFuncCtx0 is BindFunc0 for a function that takes a context, which is
+// cancelled as described in Bind.
+func BindFuncCtx0[R any](b Binder, name string, f func(context.Context) (R, error), opts ...BindOptions) error {
+	return bindTyped(b, name, f, decoders(), func(ctx context.Context, args []interface{}) (interface{}, error) {
+		return f(ctx)
+	}, opts)
+}
+
+// BindFuncCtx is BindFunc for a function that takes a context, which is
+// cancelled as described in Bind, before its argument.
+func BindFuncCtx[A, R any](b Binder, name string, f func(context.Context, A) (R, error), opts ...BindOptions) error {
+	return bindTyped(b, name, f, decoders(decodeArg[A]), func(ctx context.Context, args []interface{}) (interface{}, error) {
+		return f(ctx, argOf[A](args[0]))
+	}, opts)
+}
+
+// BindFuncCtx2 is BindFuncCtx for a function that takes two arguments.
+func BindFuncCtx2[A, B, R any](b Binder, name string, f func(context.Context, A, B) (R, error), opts ...BindOptions) error {
+	return bindTyped(b, name, f, decoders(decodeArg[A], decodeArg[B]), func(ctx context.Context, args []interface{}) (interface{}, error) {
+		return f(ctx, argOf[A](args[0]), argOf[B](args[1]))
+	}, opts)
+}
+
+// BindFuncCtx3 is BindFuncCtx for a function that takes three arguments.
+func BindFuncCtx3[A, B, C, R any](b Binder, name string, f func(context.Context, A, B, C) (R, error), opts ...BindOptions) error {
+	return bindTyped(b, name, f, decoders(decodeArg[A], decodeArg[B], decodeArg[C]), func(ctx context.Context, args []interface{}) (interface{}, error) {
+		return f(ctx, argOf[A](args[0]), argOf[B](args[1]), argOf[C](args[2]))
+	}, opts)
+}
+
+// argDecoder decodes one argument of a typed function.
+type argDecoder func(Codec, json.RawMessage) (interface{}, error)
+
+func decoders(d ...argDecoder) []argDecoder {
+	return d
+}
+
+// bindTyped binds a function through one of the generic helpers, with the
+// first of opts if any.
+func bindTyped(b Binder, name string, f interface{}, args []argDecoder, call func(context.Context, []interface{}) (interface{}, error), opts []BindOptions) error {
+	var o BindOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	return b.BindWithOptions(name, &typedFunc{
+		f: f,
+		decode: func(codec Codec, params []json.RawMessage) ([]interface{}, error) {
+			return decodeArgs(codec, params, args...)
+		},
+		call: call,
+	}, o)
+}
+
+// decodeArgs decodes params with one decoder for each parameter.
+func decodeArgs(codec Codec, params []json.RawMessage, decoders ...argDecoder) ([]interface{}, error) {
+	if len(params) != len(decoders) {
+		return nil, errArgumentsMismatch
+	}
+	args := make([]interface{}, len(params))
+	for i, decode := range decoders {
+		arg, err := decode(codec, params[i])
+		if err != nil {
+			return nil, &RPCError{Code: JSONRPCInvalidParams, Message: err.Error()}
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+func decodeArg[T any](codec Codec, param json.RawMessage) (interface{}, error) {
+	var v T
+	err := unmarshalArg(codec, param, &v)
+	return v, err
+}
+
+// argOf converts an argument returned by decodeArgs back to its type. A nil
+// interface value, which has lost its type, becomes the zero value.
+func argOf[T any](arg interface{}) T {
+	v, _ := arg.(T)
+	return v
+}
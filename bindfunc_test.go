@@ -0,0 +1,92 @@
+package webview2
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type bindfuncKey struct{}
+
+func TestBindFunc(t *testing.T) {
+	tests := []struct {
+		name string
+		bind func(b Binder) error
+		in   string
+	}{
+		{
+			"decode",
+			func(b Binder) error {
+				return BindFunc(b, "f", func(u recordUser) (string, error) { return u.Name, nil })
+			},
+			`{"method":"f","params":[{"name":"cat"}],"result":"cat"}`,
+		},
+		{
+			"decodeTwo",
+			func(b Binder) error {
+				return BindFunc2(b, "f", func(a int, s []string) (string, error) { return s[a], nil })
+			},
+			`{"method":"f","params":[1,["a","b"]],"result":"b"}`,
+		},
+		{
+			"decodeError",
+			func(b Binder) error {
+				return BindFunc(b, "f", func(a int) (int, error) { return a, nil })
+			},
+			`{"method":"f","params":["1"],"error":{"message":"json: cannot unmarshal string into Go value of type int","code":-32602}}`,
+		},
+		{
+			"tooFewArgs",
+			func(b Binder) error {
+				return BindFunc2(b, "f", func(a, b int) (int, error) { return a + b, nil })
+			},
+			`{"method":"f","params":[1],"error":{"message":"function arguments mismatch","code":-32602}}`,
+		},
+		{
+			"tooManyArgs",
+			func(b Binder) error {
+				return BindFunc(b, "f", func(a int) (int, error) { return a, nil })
+			},
+			`{"method":"f","params":[1,2],"error":{"message":"function arguments mismatch","code":-32602}}`,
+		},
+		{
+			"returnsError",
+			func(b Binder) error {
+				return BindFunc(b, "f", func(a int) (int, error) { return 0, errors.New("failed") })
+			},
+			`{"method":"f","params":[1],"error":{"message":"failed"}}`,
+		},
+		{
+			"context",
+			func(b Binder) error {
+				return BindFuncCtx(b, "f", func(ctx context.Context, suffix string) (string, error) {
+					v, _ := ctx.Value(bindfuncKey{}).(string)
+					return v + suffix, nil
+				})
+			},
+			`{"method":"f","params":["!"],"result":"replayed!"}`,
+		},
+		{
+			"contextArgsMismatch",
+			func(b Binder) error {
+				return BindFuncCtx(b, "f", func(ctx context.Context, a int) (int, error) { return a, nil })
+			},
+			`{"method":"f","params":[],"error":{"message":"function arguments mismatch","code":-32602}}`,
+		},
+	}
+	ctx := context.WithValue(context.Background(), bindfuncKey{}, "replayed")
+	for _, tt := range tests {
+		r := NewReplayer()
+		if err := tt.bind(r); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		mismatches, err := r.Replay(ctx, strings.NewReader(tt.in))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(mismatches) != 0 {
+			t.Errorf("%s: unexpected mismatches: %v", tt.name, mismatches)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"unicode"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// BindingMode specifies where and how calls to a bound function are run.
+type BindingMode int
+
+const (
+	// BindingConcurrent runs calls on the binding worker pool. Calls may run
+	// in parallel, up to BindOptions.MaxConcurrent at a time.
+	BindingConcurrent BindingMode = iota
+
+	// BindingSerial runs calls on the binding worker pool one at a time, in
+	// the order they were made.
+	BindingSerial
+
+	// BindingUIThread runs calls synchronously on the UI thread. Use this for
+	// functions that touch the native window; the window does not respond
+	// while such a call is running.
+	BindingUIThread
+)
+
+// BindOptions customizes how a bound function is called.
+type BindOptions struct {
+	// Mode specifies where calls to the function are run.
+	Mode BindingMode
+
+	// MaxConcurrent limits how many calls to the function may run at once
+	// when Mode is BindingConcurrent. Zero means no limit other than the size
+	// of the worker pool.
+	MaxConcurrent int
+
+	// AllowedOrigins lists the origins of pages that may call the function,
+	// in the format of WebViewOptions.AllowedOrigins. It narrows, rather than
+	// replaces, WebViewOptions.AllowedOrigins. If empty, any page that may
+	// call bound functions at all may call this one. The function is only
+	// defined in pages that may call it.
+	AllowedOrigins []string
+
+	// NameCase specifies how method names are converted to JavaScript names
+	// by BindObject. It is ignored by Bind.
+	NameCase NameCase
+}
+
+// NameCase specifies how Go method names are converted to JavaScript names.
+type NameCase int
+
+const (
+	// NameCaseCamel converts method names to lower camel case, so that
+	// GetUserID becomes getUserID and URLPath becomes urlPath.
+	NameCaseCamel NameCase = iota
+
+	// NameCaseGo keeps method names as they are in Go.
+	NameCaseGo
+
+	// NameCaseSnake converts method names to snake case, so that GetUserID
+	// becomes get_user_id.
+	NameCaseSnake
+)
+
+// convert returns the JavaScript name for the Go identifier name.
+func (c NameCase) convert(name string) string {
+	switch c {
+	case NameCaseCamel:
+		r := []rune(name)
+		for i := 0; i < len(r) && unicode.IsUpper(r[i]); i++ {
+			// Keep the last capital of an initialism that starts a new
+			// word, as in URLPath.
+			if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+				break
+			}
+			r[i] = unicode.ToLower(r[i])
+		}
+		return string(r)
+	case NameCaseSnake:
+		r := []rune(name)
+		var b strings.Builder
+		for i, c := range r {
+			if i > 0 && unicode.IsUpper(c) && (!unicode.IsUpper(r[i-1]) || (i+1 < len(r) && unicode.IsLower(r[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(c))
+		}
+		return b.String()
+	default:
+		return name
+	}
+}
+
+// rpcMessage is the message that the JavaScript shim of a binding sends to
+// call it, or to abort a call.
+type rpcMessage struct {
+	// RPC marks messages sent by the shim, so that other messages that
+	// happen to have the same shape are passed to OnMessage handlers.
+	RPC bool `json:"__rpc"`
+
+	ID     int               `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Abort  bool              `json:"abort,omitempty"`
+
+	// Page identifies the document that sent the message, since IDs are
+	// only unique within a document.
+	Page string `json:"page,omitempty"`
+
+	// Source is the URI of the document that sent the message.
+	Source string `json:"-"`
+}
+
+// binding is a function registered with Bind.
+type binding struct {
+	f     interface{}
+	typed *typedFunc
+	opts  BindOptions
+	queue *workqueue
+
+	// script is the ID of the init script that defines the JavaScript shim,
+	// once it is known. removed is set when the binding has been replaced or
+	// unbound, so that a script added later can be removed right away.
+	script  string
+	removed bool
+}
+
+func newBinding(f interface{}, opts BindOptions, pool *workqueue) *binding {
+	b := &binding{f: f, opts: opts}
+	if typed, ok := f.(*typedFunc); ok {
+		b.f, b.typed = typed.f, typed
+	}
+	switch opts.Mode {
+	case BindingSerial:
+		b.queue = newWorkqueue(1, pool)
+	case BindingConcurrent:
+		b.queue = newWorkqueue(opts.MaxConcurrent, pool)
+	}
+	return b
+}
+
+// typedFunc is a function bound with one of the generic helpers, such as
+// BindFunc, which decode its arguments and call it without reflection.
+type typedFunc struct {
+	f      interface{}
+	decode func(codec Codec, params []json.RawMessage) ([]interface{}, error)
+	call   func(ctx context.Context, args []interface{}) (interface{}, error)
+}
+
+// checkFunc returns an error if f cannot be bound.
+func checkFunc(f interface{}) error {
+	if _, ok := f.(*typedFunc); ok {
+		return nil
+	}
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func {
+		return errors.New("only functions can be bound")
+	}
+	if n := v.Type().NumOut(); n > 2 {
+		return errors.New("function may only return a value or a value+error")
+	} else if n == 2 && !v.Type().Out(1).Implements(errorType) {
+		return errors.New("second return value must be an error")
+	}
+	return nil
+}
+
+// reservedNames are the globals that the runtime and the binding shims
+// define or rely on, which a binding must not replace.
+var reservedNames = map[string]bool{"go": true, "_rpc": true, "external": true}
+
+// checkName returns an error if a function cannot be bound under name.
+func checkName(name string) error {
+	if top := strings.SplitN(name, ".", 2)[0]; reservedNames[top] {
+		return errors.New("cannot bind " + name + ": " + top + " is reserved")
+	}
+	return nil
+}
+
+// checkOptions returns an error if opts are not valid.
+func checkOptions(opts BindOptions) error {
+	switch opts.Mode {
+	case BindingConcurrent, BindingSerial, BindingUIThread:
+		return nil
+	}
+	return fmt.Errorf("unknown binding mode %d", opts.Mode)
+}
+
+// takesContext reports whether the first parameter of f is a context.Context,
+// which is supplied by the caller rather than by JavaScript.
+func takesContext(f interface{}) bool {
+	t := reflect.TypeOf(f)
+	return t.NumIn() > 0 && t.In(0) == contextType
+}
+
+// objectMethods returns the exported methods of obj, keyed by their names
+// converted with nameCase.
+func objectMethods(obj interface{}, nameCase NameCase) (map[string]interface{}, error) {
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() {
+		return nil, errors.New("only objects with methods can be bound")
+	}
+	methods := map[string]interface{}{}
+	for i := 0; i < v.NumMethod(); i++ {
+		name := v.Type().Method(i).Name
+		m := v.Method(i)
+		if m.Type().NumOut() > 2 {
+			return nil, errors.New("method " + name + " may only return a value or a value+error")
+		}
+		methods[nameCase.convert(name)] = m.Interface()
+	}
+	return methods, nil
+}
+
+// decode decodes the arguments of a call to the function.
+func (b *binding) decode(codec Codec, params []json.RawMessage) ([]interface{}, error) {
+	if b.typed != nil {
+		return b.typed.decode(codec, params)
+	}
+
+	t := reflect.TypeOf(b.f)
+
+	// A leading context.Context parameter is supplied by us rather than by
+	// the JavaScript caller.
+	offset := 0
+	if takesContext(b.f) {
+		offset = 1
+	}
+
+	isVariadic := t.IsVariadic()
+	numIn := t.NumIn() - offset
+	if (isVariadic && len(params) < numIn-1) || (!isVariadic && len(params) != numIn) {
+		return nil, errArgumentsMismatch
+	}
+	args := []interface{}{}
+	for i := range params {
+		arg := reflect.New(b.paramType(i))
+		if err := unmarshalArg(codec, params[i], arg.Interface()); err != nil {
+			return nil, &RPCError{Code: JSONRPCInvalidParams, Message: err.Error()}
+		}
+		args = append(args, arg.Elem().Interface())
+	}
+	return args, nil
+}
+
+// paramType returns the type of the i-th argument passed from JavaScript.
+func (b *binding) paramType(i int) reflect.Type {
+	t := reflect.TypeOf(b.f)
+	if takesContext(b.f) {
+		i++
+	}
+	if t.IsVariadic() && i >= t.NumIn()-1 {
+		return t.In(t.NumIn() - 1).Elem()
+	}
+	return t.In(i)
+}
+
+// call calls the function with arguments returned by decode.
+func (b *binding) call(ctx context.Context, args []interface{}) (interface{}, error) {
+	if b.typed != nil {
+		return b.typed.call(ctx, args)
+	}
+
+	in := []reflect.Value{}
+	if takesContext(b.f) {
+		in = append(in, reflect.ValueOf(ctx))
+	}
+	for i, arg := range args {
+		v := reflect.ValueOf(arg)
+		if !v.IsValid() {
+			// A nil interface value loses its type on the way through
+			// interface{}.
+			v = reflect.Zero(b.paramType(i))
+		}
+		in = append(in, v)
+	}
+
+	res := reflect.ValueOf(b.f).Call(in)
+	switch len(res) {
+	case 0:
+		// No results from the function, just return nil
+		return nil, nil
+
+	case 1:
+		// One result may be a value, or an error
+		if res[0].Type().Implements(errorType) {
+			if res[0].Interface() != nil {
+				return nil, res[0].Interface().(error)
+			}
+			return nil, nil
+		}
+		return res[0].Interface(), nil
+
+	case 2:
+		// Two results: first one is value, second is error
+		if !res[1].Type().Implements(errorType) {
+			return nil, errors.New("second return value must be an error")
+		}
+		if res[1].Interface() == nil {
+			return res[0].Interface(), nil
+		}
+		return res[0].Interface(), res[1].Interface().(error)
+
+	default:
+		return nil, errors.New("unexpected number of return values")
+	}
+}
+
+// callbinding decodes the arguments of call d and calls b with them through
+// middleware. JSFunc arguments call back into page, if it is not nil. An
+// unknown binding, where b is nil, returns nothing.
+func callbinding(ctx context.Context, codec Codec, middleware []Middleware, page *jsPage, b *binding, d rpcMessage) (interface{}, error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	params, err := decodeRecovered(codec, b, d)
+	if err != nil {
+		return nil, err
+	}
+	attachJSFuncs(params, page)
+
+	h := chain(func(call *Call) (interface{}, error) {
+		return b.call(call.Context, params)
+	}, middleware)
+	return h(&Call{Context: ctx, Method: d.Method, Params: params, Source: d.Source})
+}
+
+// decodeRecovered decodes the arguments of call d to b, returning a panic in
+// a codec or an UnmarshalJSON method as an error. Decoding runs before the
+// middleware, whose Call carries the decoded arguments, so Recover cannot
+// catch it.
+func decodeRecovered(codec Codec, b *binding, d rpcMessage) (params []interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic decoding arguments of bound function %s: %v\n%s", d.Method, r, debug.Stack())
+			params, err = nil, &RPCError{Code: JSONRPCInvalidParams, Message: fmt.Sprintf("%v", r)}
+		}
+	}()
+	return b.decode(codec, d.Params)
+}
+
+// settle passes the outcome of call d to done, which encodes it. If encoding
+// panics, for example in a MarshalJSON method of the result, done is called
+// again with the panic as the error. Encoding runs after the middleware, so
+// Recover cannot catch it.
+func settle(d rpcMessage, res interface{}, err error, done func(res interface{}, err error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic encoding result of bound function %s: %v\n%s", d.Method, r, debug.Stack())
+			done(nil, fmt.Errorf("%v", r))
+		}
+	}()
+	done(res, err)
+}
+
+// BindingInfo describes a bound function.
+type BindingInfo struct {
+	// Name is the name the function is bound under.
+	Name string
+
+	// Params are the types of the arguments passed from JavaScript, which
+	// do not include a leading context.Context.
+	Params []reflect.Type
+
+	// Variadic reports whether the last of Params is a variadic parameter,
+	// in which case its type is a slice.
+	Variadic bool
+
+	// Result is the type of the value that the JavaScript promise resolves
+	// to, or nil if the function only returns an error or nothing at all.
+	Result reflect.Type
+
+	// Options are the options the function was bound with.
+	Options BindOptions
+}
+
+func newBindingInfo(name string, t reflect.Type, opts BindOptions) BindingInfo {
+	info := BindingInfo{Name: name, Variadic: t.IsVariadic(), Options: opts}
+	for i := 0; i < t.NumIn(); i++ {
+		if i == 0 && t.In(i) == contextType {
+			continue
+		}
+		info.Params = append(info.Params, t.In(i))
+	}
+	if t.NumOut() > 0 && !t.Out(0).Implements(errorType) {
+		info.Result = t.Out(0)
+	}
+	return info
+}
@@ -0,0 +1,42 @@
+package webview2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		f    interface{}
+		opts BindOptions
+	}{
+		{"notAFunc", 42, BindOptions{}},
+		{"tooManyResults", func() (int, int, error) { return 0, 0, nil }, BindOptions{}},
+		{"secondNotError", func() (int, int) { return 0, 0 }, BindOptions{}},
+		{"unknownMode", func() {}, BindOptions{Mode: BindingUIThread + 1}},
+		{"negativeMode", func() {}, BindOptions{Mode: -1}},
+		{"go", func() {}, BindOptions{}},
+		{"go.on", func() {}, BindOptions{}},
+		{"_rpc", func() {}, BindOptions{}},
+		{"external.invoke", func() {}, BindOptions{}},
+	}
+	for _, tt := range tests {
+		if err := NewReplayer().BindWithOptions(tt.name, tt.f, tt.opts); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+	if err := NewReplayer().BindObject("go", &tsUser{}); err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("BindObject: expected an error for a reserved namespace, got %v", err)
+	}
+	for _, name := range []string{"golang", "app.go", "externals"} {
+		if err := NewReplayer().Bind(name, func() {}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	for _, mode := range []BindingMode{BindingConcurrent, BindingSerial, BindingUIThread} {
+		if err := NewReplayer().BindWithOptions("f", func() {}, BindOptions{Mode: mode}); err != nil {
+			t.Errorf("mode %d: %v", mode, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Command tsgen generates TypeScript declarations for bound Go functions.
+//
+// The functions are read from an exported function in your own package that
+// returns them keyed by the name they are bound under:
+//
+//	func Bindings() map[string]interface{} {
+//		return map[string]interface{}{
+//			"add":    Add,
+//			"search": Search,
+//		}
+//	}
+//
+// which your application can pass to Bind one by one. Then, from within your
+// module:
+//
+//	go run github.com/jchv/go-webview2/cmd/tsgen -pkg ./app -func Bindings -o frontend/bindings.d.ts
+//
+// tsgen builds and runs a small program that calls the function and passes
+// the result to webview2.WriteTypeScript, so the package must build for the
+// host operating system.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const program = `package main
+
+import (
+	"fmt"
+	"os"
+
+	webview2 "github.com/jchv/go-webview2"
+	target %q
+)
+
+func main() {
+	if err := webview2.WriteTypeScript(os.Stdout, target.%s()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`
+
+func main() {
+	pkg := flag.String("pkg", ".", "package containing the bindings function")
+	fn := flag.String("func", "Bindings", "exported function returning map[string]interface{} of bindings")
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("tsgen: ")
+
+	importPath, err := goList(*pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "tsgen")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(src, []byte(fmt.Sprintf(program, importPath, *fn)), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command("go", "run", src)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		_, _ = os.Stdout.Write(stdout.Bytes())
+		return
+	}
+	if err := ioutil.WriteFile(*out, stdout.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func goList(pkg string) (string, error) {
+	cmd := exec.Command("go", "list", "-f", "{{.ImportPath}}", pkg)
+	cmd.Stderr = os.Stderr
+	b, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
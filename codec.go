@@ -0,0 +1,32 @@
+package webview2
+
+import "encoding/json"
+
+// Codec encodes the arguments and results of bound functions. Values still
+// travel between the page and Go inside JSON messages, so a codec for a
+// binary format would typically carry its data as a base64 string.
+//
+// The page must use a matching codec, installed as window.go.codec: an
+// object whose encode method is applied to each argument before it is sent
+// and whose decode method is applied to each result when it arrives. By
+// default both return their argument unchanged.
+type Codec interface {
+	// Unmarshal decodes an argument sent by the page into v, which is a
+	// pointer to a value of the parameter type.
+	Unmarshal(data json.RawMessage, v interface{}) error
+
+	// Marshal encodes a result to send to the page. The encoded value must
+	// be valid JSON.
+	Marshal(v interface{}) (json.RawMessage, error)
+}
+
+// JSONCodec is the default Codec, which uses encoding/json.
+type JSONCodec struct{}
+
+func (JSONCodec) Unmarshal(data json.RawMessage, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JSONCodec) Marshal(v interface{}) (json.RawMessage, error) {
+	return json.Marshal(v)
+}
@@ -1,6 +1,12 @@
 package webview2
 
-import "unsafe"
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+	"unsafe"
+)
 
 // This is copied from webview/webview.
 // The documentation is included for convenience.
@@ -23,31 +29,97 @@ const (
 )
 
 // WebView is the interface for the webview.
+//
+// Its methods may be called from any goroutine. Methods that act on the
+// window or the page run right away when called from the UI thread, and are
+// otherwise queued to it with Dispatch, in the order they were called,
+// without waiting for them to run.
 type WebView interface {
 
 	// Run runs the main loop until it's terminated. After this function exits -
-	// you must destroy the webview.
+	// you must destroy the webview. To show more than one window, create them
+	// with an App and use its Run method instead.
 	Run()
 
+	// RunContext is like Run, but also stops the main loop when ctx is
+	// cancelled, in which case it returns ctx.Err(). It returns
+	// ErrBrowserProcessExited if the browser process exits unexpectedly, or
+	// another error if the message loop fails. It returns nil when the loop
+	// is stopped by Terminate.
+	RunContext(ctx context.Context) error
+
 	// Terminate stops the main loop. It is safe to call this function from
 	// a background thread.
 	Terminate()
 
 	// Dispatch posts a function to be executed on the main thread. You normally
 	// do not need to call this function, unless you want to tweak the native
-	// window.
+	// window. Functions run in the order they were posted, including while
+	// the window is being moved or resized or a modal dialog is open.
 	Dispatch(f func())
 
-	// Destroy destroys a webview and closes the native window.
+	// DispatchAfter runs f on the main thread once d has passed, using a timer
+	// of the native window. Calling cancel before then stops f from running.
+	// Timers are stopped when the window is destroyed.
+	DispatchAfter(d time.Duration, f func()) (cancel func())
+
+	// DispatchEvery runs f on the main thread every time d passes, like
+	// DispatchAfter, until cancel is called or the window is destroyed.
+	DispatchEvery(d time.Duration, f func()) (cancel func())
+
+	// DispatchSync runs f on the main thread, like Dispatch, and waits for it
+	// to return. When called from the main thread, f runs right away. If the
+	// window is destroyed before f has run, DispatchSync returns
+	// ErrDestroyed.
+	DispatchSync(f func() (interface{}, error)) (interface{}, error)
+
+	// Destroy destroys a webview and closes the native window, without asking
+	// the hooks added with OnCloseRequested.
 	Destroy()
 
+	// Close asks for the window to be closed, as if the user had clicked its
+	// close button. The window only closes if every hook added with
+	// OnCloseRequested allows it.
+	Close()
+
+	// OnReady adds a function to call on the UI thread once the webview is
+	// ready and the main loop is running. If that has already happened, f is
+	// dispatched right away.
+	OnReady(f func())
+
+	// OnCloseRequested adds a function that is called on the UI thread when
+	// the user or Close asks for the window to be closed. If f returns false,
+	// the window stays open. To decide asynchronously, for example after
+	// asking the page whether there is unsaved work, return false and call
+	// Destroy later if the window should close after all:
+	//
+	//	w.OnCloseRequested(func() bool {
+	//		go func() {
+	//			ok, err := w.EvalResult(ctx, "confirm('Discard unsaved changes?')")
+	//			if err == nil && string(ok) == "true" {
+	//				w.Destroy()
+	//			}
+	//		}()
+	//		return false
+	//	})
+	OnCloseRequested(f func() bool)
+
+	// OnClosed adds a function that is called on the UI thread once the
+	// window is about to close, after the close hooks have allowed it.
+	OnClosed(f func())
+
+	// OnDestroyed adds a function that is called on the UI thread when the
+	// native window is destroyed. For a window created with App.NewWindow, the
+	// main loop of the App is then stopped only if the quit policy says so;
+	// otherwise it is stopped right after f returns.
+	OnDestroyed(f func())
+
 	// Window returns a native window handle pointer. When using GTK backend the
 	// pointer is GtkWindow pointer, when using Cocoa backend the pointer is
 	// NSWindow pointer, when using Win32 backend the pointer is HWND pointer.
 	Window() unsafe.Pointer
 
-	// SetTitle updates the title of the native window. Must be called from the UI
-	// thread.
+	// SetTitle updates the title of the native window.
 	SetTitle(title string)
 
 	// SetSize updates native window size. See Hint constants.
@@ -68,10 +140,43 @@ type WebView interface {
 	Init(js string)
 
 	// Eval evaluates arbitrary JavaScript code. Evaluation happens asynchronously,
-	// also the result of the expression is ignored. Use RPC bindings if you want
-	// to receive notifications about the results of the evaluation.
+	// also the result of the expression is ignored. Use EvalResult if you want
+	// to receive the result of the evaluation.
 	Eval(js string)
 
+	// EvalResult evaluates arbitrary JavaScript code in the global scope and
+	// waits for its result, encoded as JSON. If the code throws, the returned
+	// error is a *ScriptError. EvalResult stops waiting when ctx is done, or
+	// returns ErrDestroyed when the window is destroyed, but the code keeps
+	// running. It must not be called from the UI thread.
+	//
+	// The code is not passed to eval, so it also works on pages whose
+	// Content-Security-Policy forbids eval. Instead it runs inside a block,
+	// so declarations made with let, const or class do not outlive it, and
+	// code with a syntax error does not run at all and gives a null result.
+	EvalResult(ctx context.Context, js string) (json.RawMessage, error)
+
+	// Emit sends an event to the page, where it is delivered to handlers
+	// registered with window.go.on(event, handler). payload is encoded with
+	// encoding/json and passed to each handler as its only argument.
+	Emit(event string, payload interface{}) error
+
+	// OnMessage adds a handler for messages that the page sends with
+	// window.chrome.webview.postMessage. handler receives the URI of the
+	// sending document and the message encoded as JSON, so a string message
+	// arrives as a JSON string. Messages used to call bound functions are not
+	// passed to handler. Handlers are called on the UI thread.
+	OnMessage(handler func(source string, msg json.RawMessage))
+
+	// PostMessageJSON encodes msg with encoding/json and posts it to the page,
+	// which receives the decoded value as the data of a message event on
+	// window.chrome.webview.
+	PostMessageJSON(msg interface{}) error
+
+	// PostMessageString posts msg to the page, which receives it as the
+	// string data of a message event on window.chrome.webview.
+	PostMessageString(msg string)
+
 	// Bind binds a callback function so that it will appear under the given name
 	// as a global JavaScript function. Internally it uses webview_init().
 	// Callback receives a request string and a user-provided argument pointer.
@@ -80,5 +185,66 @@ type WebView interface {
 	//
 	// f must be a function
 	// f must return either value and error or just error
+	//
+	// A name containing dots, such as "app.quit", defines the function as a
+	// property of nested objects that are created as needed. The names go,
+	// _rpc and external, and names within them, are reserved for the runtime
+	// and cannot be bound.
+	//
+	// If f returns an error, the promise is rejected with an Error carrying
+	// its message. Errors that implement CodedError also set the code and
+	// data properties of the Error.
+	//
+	// If the first parameter of f is a context.Context, it is not taken from
+	// the JavaScript arguments. Instead, f receives a context that is cancelled
+	// when the page navigates away, when the window is destroyed, or when the
+	// JavaScript caller passes an AbortSignal as its last argument and aborts
+	// it. If the caller's page or window is gone by the time f returns, the
+	// result is discarded, so f can stop early once the context is done.
+	//
+	// A JavaScript function passed as an argument is received by a *JSFunc
+	// parameter, which f can use to call it back.
+	//
+	// Calls to f run on a pool of worker goroutines, so f must not touch the
+	// native window; use Dispatch or BindWithOptions with BindingUIThread if
+	// it needs to.
+	//
+	// BindFunc and its variants check the signature of f at compile time
+	// instead.
 	Bind(name string, f interface{}) error
+
+	// BindWithOptions is like Bind, but allows customizing how calls to f are
+	// scheduled. See BindOptions.
+	BindWithOptions(name string, f interface{}, opts BindOptions) error
+
+	// BindObject binds every exported method of obj as a function of the
+	// JavaScript object window[namespace]. Method names are converted to lower
+	// camel case; use BindObjectWithOptions to change this. Each method is
+	// bound as if by Bind, under the name namespace + "." + method, so Unbind
+	// can be used to remove individual methods.
+	BindObject(namespace string, obj interface{}) error
+
+	// BindObjectWithOptions is like BindObject, but allows customizing how
+	// method names are converted and how calls are scheduled.
+	BindObjectWithOptions(namespace string, obj interface{}, opts BindOptions) error
+
+	// Use adds middleware around every call to a bound function. Middleware
+	// is called in the order it was added, after the Recover middleware that
+	// is always installed.
+	Use(middleware ...Middleware)
+
+	// Unbind removes a binding created with Bind, both from the current page
+	// and from pages loaded later. Calls that are already running are not
+	// interrupted.
+	Unbind(name string) error
+
+	// Bindings describes the functions that are currently bound, sorted by
+	// name. The page can find the same information in window.go.__bindings,
+	// keyed by name, with parameter and result types given as TypeScript
+	// types.
+	Bindings() []BindingInfo
+
+	// WriteTypeScript writes TypeScript declarations for the functions that
+	// are currently bound. See the package-level WriteTypeScript.
+	WriteTypeScript(out io.Writer) error
 }
@@ -0,0 +1,11 @@
+package webview2
+
+// DispatchValue runs f on the main thread of w and returns its result, like
+// WebView.DispatchSync, without the need for a type assertion.
+func DispatchValue[T any](w WebView, f func() (T, error)) (T, error) {
+	v, err := w.DispatchSync(func() (interface{}, error) {
+		return f()
+	})
+	t, _ := v.(T)
+	return t, err
+}
@@ -0,0 +1,100 @@
+package webview2
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrDestroyed is returned when waiting for the UI thread of a window that
+// has been destroyed.
+var ErrDestroyed = errors.New("window has been destroyed")
+
+// ErrBrowserProcessExited is returned by RunContext when the WebView2
+// browser process exits unexpectedly.
+var ErrBrowserProcessExited = errors.New("browser process exited")
+
+// ScriptError is returned when JavaScript evaluated from Go throws.
+type ScriptError struct {
+	// Name is the name of the error, such as "TypeError". It is empty if
+	// the thrown value was not an Error.
+	Name string `json:"name"`
+
+	// Message is the error message, or the thrown value converted to a
+	// string if it was not an Error.
+	Message string `json:"message"`
+
+	// Stack is the JavaScript stack trace, if available.
+	Stack string `json:"stack"`
+
+	// Code is the code property of the thrown value converted to a string,
+	// or empty if it has none.
+	Code string `json:"code"`
+
+	// Data is the data property of the thrown value encoded as JSON, or nil
+	// if it has none.
+	Data json.RawMessage `json:"data"`
+}
+
+func (e *ScriptError) Error() string {
+	if e.Name == "" {
+		return e.Message
+	}
+	return e.Name + ": " + e.Message
+}
+
+// CodedError can be implemented by errors returned from bound functions to
+// give the JavaScript caller more than a message. The promise is rejected
+// with an Error whose code and data properties are set from ErrorCode and
+// ErrorData; data is encoded with encoding/json.
+type CodedError interface {
+	error
+	ErrorCode() int
+	ErrorData() interface{}
+}
+
+// RPCError is a CodedError with a fixed code, message and data.
+type RPCError struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+func (e *RPCError) Error() string { return e.Message }
+
+func (e *RPCError) ErrorCode() int { return e.Code }
+
+func (e *RPCError) ErrorData() interface{} { return e.Data }
+
+// Error codes defined by the JSON-RPC 2.0 specification.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+
+	// JSONRPCServerError is used for errors returned by bound functions that
+	// do not implement CodedError.
+	JSONRPCServerError = -32000
+)
+
+var errArgumentsMismatch = &RPCError{Code: JSONRPCInvalidParams, Message: "function arguments mismatch"}
+
+// rpcError is how an error is sent to JavaScript. Code and data are only set
+// for errors that implement CodedError.
+type rpcError struct {
+	Message string      `json:"message"`
+	Code    *int        `json:"code,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func newRPCError(err error) rpcError {
+	e := rpcError{Message: err.Error()}
+	var coded CodedError
+	if errors.As(err, &coded) {
+		code := coded.ErrorCode()
+		e.Code = &code
+		e.Data = coded.ErrorData()
+	}
+	return e
+}
@@ -1,6 +1,6 @@
 module github.com/jchv/go-webview2
 
-go 1.16
+go 1.18
 
 require (
 	github.com/jchv/go-winloader v0.0.0-20250406163304-c1995be93bd1
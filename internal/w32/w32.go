@@ -42,6 +42,9 @@ var (
 	User32SetWindowPos       = user32.NewProc("SetWindowPos")
 	User32IsDialogMessage    = user32.NewProc("IsDialogMessage")
 	User32GetAncestor        = user32.NewProc("GetAncestor")
+	User32SetTimer           = user32.NewProc("SetTimer")
+	User32SendMessageW       = user32.NewProc("SendMessageW")
+	User32KillTimer          = user32.NewProc("KillTimer")
 )
 
 const (
@@ -74,6 +77,11 @@ const (
 	SWShow = 5
 )
 
+const (
+	IconSmall = 0
+	IconBig   = 1
+)
+
 const (
 	SWPNoZOrder     = 0x0004
 	SWPNoActivate   = 0x0010
@@ -89,7 +97,9 @@ const (
 	WMClose         = 0x0010
 	WMQuit          = 0x0012
 	WMGetMinMaxInfo = 0x0024
+	WMSetIcon       = 0x0080
 	WMNCLButtonDown = 0x00A1
+	WMTimer         = 0x0113
 	WMMoving        = 0x0216
 	WMApp           = 0x8000
 )
@@ -0,0 +1,126 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+var jsFuncType = reflect.TypeOf((*JSFunc)(nil))
+
+// ErrJSFuncReleased is returned when calling a JSFunc that has been released,
+// or whose page has navigated away.
+var ErrJSFuncReleased = errors.New("JavaScript function has been released")
+
+// JSFunc is a JavaScript function passed as an argument to a bound function,
+// which receives it as a *JSFunc parameter. It lets the bound function call
+// back into the exact caller, for example to report progress:
+//
+//	w.Bind("importFile", func(ctx context.Context, path string, progress *webview2.JSFunc) error {
+//		defer progress.Release()
+//		for i := 0; i < 100; i++ {
+//			progress.Call(i)
+//			// ...
+//		}
+//		return nil
+//	})
+//
+// and in JavaScript:
+//
+//	await importFile(path, (percent) => bar.value = percent);
+//
+// The function stays callable after the bound function returns, until it is
+// released with Release, the JSFunc is garbage collected, or the page
+// navigates away.
+type JSFunc struct {
+	id       int
+	page     *jsPage
+	released int32
+}
+
+// jsPage is the page that a call to a bound function came from, which JSFunc
+// arguments call back into.
+type jsPage struct {
+	// ctx is cancelled when the page navigates away.
+	ctx   context.Context
+	codec Codec
+
+	// eval runs a script in the page. It may be called from any goroutine.
+	eval func(js string)
+}
+
+func (f *JSFunc) UnmarshalJSON(data []byte) error {
+	var v struct {
+		ID *int `json:"__jsfunc"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil || v.ID == nil {
+		return errors.New("argument is not a function")
+	}
+	f.id = *v.ID
+	return nil
+}
+
+// attach connects f to the page that passed it, so that it can be called.
+func (f *JSFunc) attach(page *jsPage) {
+	f.page = page
+	runtime.SetFinalizer(f, (*JSFunc).Release)
+}
+
+// Call calls the JavaScript function with args, each encoded with the codec
+// of the webview. It returns without waiting for the function to run, and it
+// may be called from any goroutine. Whatever the function returns or throws
+// is ignored.
+func (f *JSFunc) Call(args ...interface{}) error {
+	if f.page == nil || atomic.LoadInt32(&f.released) != 0 || f.page.ctx.Err() != nil {
+		return ErrJSFuncReleased
+	}
+	encoded := make([]string, len(args))
+	for i, arg := range args {
+		b, err := f.page.codec.Marshal(arg)
+		if err != nil {
+			return err
+		}
+		encoded[i] = string(b)
+	}
+	f.page.eval("window.go.__call(" + strconv.Itoa(f.id) + ", [" + strings.Join(encoded, ", ") + "])")
+	return nil
+}
+
+// Release lets the page forget the JavaScript function. Calls made after
+// Release return ErrJSFuncReleased. It is safe to call Release more than
+// once.
+func (f *JSFunc) Release() {
+	if !atomic.CompareAndSwapInt32(&f.released, 0, 1) || f.page == nil {
+		return
+	}
+	runtime.SetFinalizer(f, nil)
+	if f.page.ctx.Err() == nil {
+		f.page.eval("window.go.__release(" + strconv.Itoa(f.id) + ")")
+	}
+}
+
+// unmarshalArg decodes an argument of a bound function into v. JavaScript
+// functions are sent as plain JSON, whatever the codec.
+func unmarshalArg(codec Codec, data json.RawMessage, v interface{}) error {
+	if _, ok := v.(**JSFunc); ok {
+		return json.Unmarshal(data, v)
+	}
+	return codec.Unmarshal(data, v)
+}
+
+// attachJSFuncs attaches the JSFunc arguments of a call to page.
+func attachJSFuncs(args []interface{}, page *jsPage) {
+	if page == nil {
+		return
+	}
+	for _, arg := range args {
+		if f, ok := arg.(*JSFunc); ok && f != nil {
+			f.attach(page)
+		}
+	}
+}
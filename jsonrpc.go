@@ -0,0 +1,189 @@
+//go:build windows
+// +build windows
+
+package webview2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+type jsonrpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+}
+
+type jsonrpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// isJSONRPC reports whether payload is a JSON-RPC 2.0 request or batch. An
+// empty batch, or one that does not start with an object, is treated as an
+// invalid batch, which must be answered with an error. So is a payload that
+// is not valid JSON but mentions a "jsonrpc" member, which must be answered
+// with a parse error.
+func isJSONRPC(payload []byte) bool {
+	payload = bytes.TrimSpace(payload)
+	if !json.Valid(payload) {
+		return bytes.Contains(payload, []byte(`"jsonrpc"`))
+	}
+	if len(payload) > 0 && payload[0] == '[' {
+		var batch []json.RawMessage
+		if json.Unmarshal(payload, &batch) != nil {
+			return false
+		}
+		if len(batch) == 0 || bytes.TrimSpace(batch[0])[0] != '{' {
+			return true
+		}
+		var req struct {
+			JSONRPC string `json:"jsonrpc"`
+		}
+		return json.Unmarshal(batch[0], &req) == nil && req.JSONRPC == "2.0"
+	}
+	var req struct {
+		JSONRPC string `json:"jsonrpc"`
+	}
+	return json.Unmarshal(payload, &req) == nil && req.JSONRPC == "2.0"
+}
+
+// jsonrpcMessage handles a JSON-RPC request or batch from the page and posts
+// the response back as a string or as JSON, matching the request.
+func (w *webview) jsonrpcMessage(payload []byte, source string, asString bool) {
+	respond := func(v interface{}) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			b, _ = json.Marshal(newJSONRPCResponse(w.codec, nil, nil, err))
+		}
+		w.Dispatch(func() {
+			if asString {
+				w.browser.PostWebMessageAsString(string(b))
+			} else {
+				w.browser.PostWebMessageAsJSON(string(b))
+			}
+		})
+	}
+
+	payload = bytes.TrimSpace(payload)
+	if !json.Valid(payload) {
+		respond(newJSONRPCResponse(w.codec, nil, nil, &RPCError{Code: JSONRPCParseError, Message: "parse error"}))
+		return
+	}
+	if payload[0] != '[' {
+		w.jsonrpcCall(payload, source, func(res *jsonrpcResponse) {
+			if res != nil {
+				respond(res)
+			}
+		})
+		return
+	}
+
+	var batch []json.RawMessage
+	_ = json.Unmarshal(payload, &batch)
+	if len(batch) == 0 {
+		respond(newJSONRPCResponse(w.codec, nil, nil, &RPCError{Code: JSONRPCInvalidRequest, Message: "invalid request"}))
+		return
+	}
+	var m sync.Mutex
+	responses := []*jsonrpcResponse{}
+	pending := len(batch)
+	for _, req := range batch {
+		w.jsonrpcCall(req, source, func(res *jsonrpcResponse) {
+			m.Lock()
+			if res != nil {
+				responses = append(responses, res)
+			}
+			pending--
+			last := pending == 0
+			m.Unlock()
+
+			// A batch of only notifications gets no response at all.
+			if last && len(responses) > 0 {
+				respond(responses)
+			}
+		})
+	}
+}
+
+// jsonrpcCall calls the function named by a single JSON-RPC request and
+// passes the response to done, or nil if the request is a notification.
+func (w *webview) jsonrpcCall(raw json.RawMessage, source string, done func(*jsonrpcResponse)) {
+	var req jsonrpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		// The ID is only known if the request was an object.
+		done(newJSONRPCResponse(w.codec, req.ID, nil, &RPCError{Code: JSONRPCInvalidRequest, Message: "invalid request"}))
+		return
+	}
+
+	reply := func(res interface{}, err error) {
+		if req.ID == nil {
+			done(nil)
+			return
+		}
+		done(newJSONRPCResponse(w.codec, req.ID, res, err))
+	}
+
+	// Check the origin before looking up the method, so that a page that
+	// may not call bound functions cannot find out which ones exist.
+	if origin := originOf(source); !originAllowed(w.allowedOrigins, origin) {
+		reply(nil, errors.New("calls from "+origin+" are not allowed"))
+		return
+	}
+
+	w.m.Lock()
+	_, ok := w.bindings[req.Method]
+	w.m.Unlock()
+	if !ok {
+		reply(nil, &RPCError{Code: JSONRPCMethodNotFound, Message: "method not found: " + req.Method})
+		return
+	}
+
+	var params []json.RawMessage
+	switch p := bytes.TrimSpace(req.Params); {
+	case len(p) == 0 || bytes.Equal(p, []byte("null")):
+	case p[0] == '[':
+		if err := json.Unmarshal(p, &params); err != nil {
+			reply(nil, &RPCError{Code: JSONRPCInvalidParams, Message: err.Error()})
+			return
+		}
+	case p[0] == '{':
+		params = []json.RawMessage{p}
+	default:
+		reply(nil, &RPCError{Code: JSONRPCInvalidParams, Message: "params must be an array or an object"})
+		return
+	}
+
+	w.invoke(rpcMessage{Method: req.Method, Params: params, Source: source}, false, reply)
+}
+
+func newJSONRPCResponse(codec Codec, id json.RawMessage, res interface{}, err error) *jsonrpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	r := &jsonrpcResponse{JSONRPC: "2.0", ID: id}
+	if err == nil {
+		r.Result, err = codec.Marshal(res)
+	}
+	if err != nil {
+		e := newRPCError(err)
+		code := JSONRPCServerError
+		if e.Code != nil {
+			code = *e.Code
+		}
+		r.Result = nil
+		r.Error = &jsonrpcError{Code: code, Message: e.Message, Data: e.Data}
+	}
+	return r
+}
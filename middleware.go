@@ -0,0 +1,57 @@
+package webview2
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// Call describes a call to a bound function from JavaScript.
+type Call struct {
+	// Context is passed to the function if it accepts one. Middleware may
+	// replace it, for example to attach values or a deadline.
+	Context context.Context
+
+	// Method is the name the function is bound under.
+	Method string
+
+	// Params holds the decoded arguments, not including the context. They
+	// must not be modified.
+	Params []interface{}
+
+	// Source is the URI of the document that made the call.
+	Source string
+}
+
+// Handler handles a call to a bound function, returning its result.
+type Handler func(call *Call) (interface{}, error)
+
+// Middleware wraps the handling of calls to bound functions. It can inspect
+// or change the call and the result, or return an error without calling next
+// at all, which rejects the JavaScript promise with that error.
+type Middleware func(next Handler) Handler
+
+// Recover is middleware that turns a panic in a bound function into an error,
+// so that the JavaScript promise is rejected with the panic message instead
+// of the process crashing. It is always installed, outside of any middleware
+// added with Use.
+func Recover(next Handler) Handler {
+	return func(call *Call) (res interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("panic in bound function %s: %v\n%s", call.Method, r, debug.Stack())
+				err = fmt.Errorf("%v", r)
+			}
+		}()
+		return next(call)
+	}
+}
+
+// chain wraps h in middleware, with the first middleware outermost.
+func chain(h Handler, middleware []Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,107 @@
+package webview2
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// originOf returns the origin of the document at uri, such as
+// "https://example.com:8080". Documents without a host, such as those loaded
+// with SetHtml (about:blank) or from data URIs, have an origin made of just
+// the scheme, such as "about:" or "data:".
+func originOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" {
+		return ""
+	}
+	if u.Host == "" {
+		return strings.ToLower(u.Scheme) + ":"
+	}
+	return withoutDefaultPort(strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host))
+}
+
+// withoutDefaultPort removes the port from an http or https origin if it is
+// the default one for the scheme, which browsers leave out of origins.
+func withoutDefaultPort(origin string) string {
+	switch {
+	case strings.HasPrefix(origin, "http://"):
+		return strings.TrimSuffix(origin, ":80")
+	case strings.HasPrefix(origin, "https://"):
+		return strings.TrimSuffix(origin, ":443")
+	}
+	return origin
+}
+
+// originAllowed reports whether origin matches one of the patterns in
+// allowed. An empty list allows every origin. A pattern is either an origin,
+// "*" to match any origin, or an origin with a "*." host prefix, such as
+// "https://*.example.com", to match any subdomain.
+func originAllowed(allowed []string, origin string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	if origin == "" {
+		return false
+	}
+	for _, pattern := range allowed {
+		pattern = normalizePattern(pattern)
+		switch {
+		case pattern == "*" || pattern == origin:
+			return true
+		case strings.Contains(pattern, "://*."):
+			i := strings.Index(pattern, "://*.")
+			scheme, domain := pattern[:i+3], pattern[i+4:]
+			if strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, domain) && len(origin) > len(scheme)+len(domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalizePattern(pattern string) string {
+	return withoutDefaultPort(strings.ToLower(strings.TrimSuffix(pattern, "/")))
+}
+
+// originGuard wraps script so that it only runs in documents whose origin is
+// allowed by every one of allowlists, as originAllowed decides for calls, so
+// that pages that may not call bound functions cannot see them either.
+func originGuard(script string, allowlists ...[]string) string {
+	lists := [][]string{}
+	for _, allowed := range allowlists {
+		if len(allowed) == 0 {
+			continue
+		}
+		patterns := make([]string, len(allowed))
+		for i, pattern := range allowed {
+			patterns[i] = normalizePattern(pattern)
+		}
+		lists = append(lists, patterns)
+	}
+	if len(lists) == 0 {
+		return script
+	}
+	b, _ := json.Marshal(lists)
+	return "if ((" + originGuardScript + ")(" + string(b) + ")) " + script
+}
+
+// originGuardScript is the JavaScript version of originOf and
+// originAllowed, applied to the current document.
+const originGuardScript = `function(lists) {
+	var origin = location.host ? location.protocol + '//' + location.host : location.protocol;
+	return lists.every(function(patterns) {
+	  return patterns.some(function(pattern) {
+		if (pattern === '*' || pattern === origin) {
+		  return true;
+		}
+		var i = pattern.indexOf('://*.');
+		if (i < 0) {
+		  return false;
+		}
+		var scheme = pattern.slice(0, i + 3), domain = pattern.slice(i + 4);
+		return origin.indexOf(scheme) === 0 && origin.length > scheme.length + domain.length &&
+		  origin.slice(-domain.length) === domain;
+	  });
+	});
+  }`
@@ -0,0 +1,107 @@
+package edge
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+type _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerVtbl struct {
+	_IUnknownVtbl
+	Invoke ComProc
+}
+
+type ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler struct {
+	vtbl *_ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerVtbl
+	impl _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerImpl
+}
+
+func _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerIUnknownQueryInterface(this *ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler, refiid, object uintptr) uintptr {
+	return this.impl.QueryInterface(refiid, object)
+}
+
+func _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerIUnknownAddRef(this *ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler) uintptr {
+	return this.impl.AddRef()
+}
+
+func _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerIUnknownRelease(this *ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler) uintptr {
+	return this.impl.Release()
+}
+
+func _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerInvoke(this *ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler, errorCode uintptr, id *uint16) uintptr {
+	return this.impl.AddScriptToExecuteOnDocumentCreatedCompleted(errorCode, id)
+}
+
+type _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerImpl interface {
+	_IUnknownImpl
+	AddScriptToExecuteOnDocumentCreatedCompleted(errorCode uintptr, id *uint16) uintptr
+}
+
+var _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerFn = _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerVtbl{
+	_IUnknownVtbl{
+		NewComProc(_ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerIUnknownQueryInterface),
+		NewComProc(_ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerIUnknownAddRef),
+		NewComProc(_ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerIUnknownRelease),
+	},
+	NewComProc(_ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerInvoke),
+}
+
+func newICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler(impl _ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerImpl) *ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler {
+	return &ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler{
+		vtbl: &_ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandlerFn,
+		impl: impl,
+	}
+}
+
+// addScriptCompleted receives the result of a single
+// AddScriptToExecuteOnDocumentCreated call.
+type addScriptCompleted struct {
+	pendingHandler
+	handler *ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler
+	done    func(id string, err error)
+}
+
+func (a *addScriptCompleted) QueryInterface(_, _ uintptr) uintptr {
+	return 0
+}
+
+func (a *addScriptCompleted) AddScriptToExecuteOnDocumentCreatedCompleted(errorCode uintptr, id *uint16) uintptr {
+	a.complete()
+	if int32(errorCode) < 0 {
+		a.done("", windows.Errno(errorCode))
+		return 0
+	}
+	a.done(windows.UTF16PtrToString(id), nil)
+	return 0
+}
+
+func (i *ICoreWebView2) AddScriptToExecuteOnDocumentCreated(script string, handler *ICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler) error {
+	_script, err := windows.UTF16PtrFromString(script)
+	if err != nil {
+		return err
+	}
+	_, _, err = i.vtbl.AddScriptToExecuteOnDocumentCreated.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(_script)),
+		uintptr(unsafe.Pointer(handler)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return err
+	}
+	return nil
+}
+
+func (i *ICoreWebView2) RemoveScriptToExecuteOnDocumentCreated(id string) error {
+	_id, err := windows.UTF16PtrFromString(id)
+	if err != nil {
+		return err
+	}
+	_, _, err = i.vtbl.RemoveScriptToExecuteOnDocumentCreated.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(_id)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package edge
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+type _ICoreWebView2ContentLoadingEventArgsVtbl struct {
+	_IUnknownVtbl
+	GetIsErrorPage  ComProc
+	GetNavigationId ComProc
+}
+
+type ICoreWebView2ContentLoadingEventArgs struct {
+	vtbl *_ICoreWebView2ContentLoadingEventArgsVtbl
+}
+
+func (i *ICoreWebView2ContentLoadingEventArgs) AddRef() uintptr {
+	r, _, _ := i.vtbl.AddRef.Call()
+	return r
+}
+
+func (i *ICoreWebView2ContentLoadingEventArgs) GetIsErrorPage() (bool, error) {
+	var err error
+	var isErrorPage int32
+	_, _, err = i.vtbl.GetIsErrorPage.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&isErrorPage)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return false, err
+	}
+	return isErrorPage != 0, nil
+}
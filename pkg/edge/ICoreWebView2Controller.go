@@ -130,3 +130,19 @@ func (i *ICoreWebView2Controller) MoveFocus(reason uintptr) error {
 	}
 	return nil
 }
+
+func (i *ICoreWebView2Controller) Release() uintptr {
+	r, _, _ := i.vtbl.Release.Call(uintptr(unsafe.Pointer(i)))
+	return r
+}
+
+func (i *ICoreWebView2Controller) Close() error {
+	var err error
+	_, _, err = i.vtbl.Close.Call(
+		uintptr(unsafe.Pointer(i)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return err
+	}
+	return nil
+}
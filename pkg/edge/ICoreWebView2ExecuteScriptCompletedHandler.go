@@ -0,0 +1,71 @@
+package edge
+
+import "golang.org/x/sys/windows"
+
+type _ICoreWebView2ExecuteScriptCompletedHandlerVtbl struct {
+	_IUnknownVtbl
+	Invoke ComProc
+}
+
+type ICoreWebView2ExecuteScriptCompletedHandler struct {
+	vtbl *_ICoreWebView2ExecuteScriptCompletedHandlerVtbl
+	impl _ICoreWebView2ExecuteScriptCompletedHandlerImpl
+}
+
+func _ICoreWebView2ExecuteScriptCompletedHandlerIUnknownQueryInterface(this *ICoreWebView2ExecuteScriptCompletedHandler, refiid, object uintptr) uintptr {
+	return this.impl.QueryInterface(refiid, object)
+}
+
+func _ICoreWebView2ExecuteScriptCompletedHandlerIUnknownAddRef(this *ICoreWebView2ExecuteScriptCompletedHandler) uintptr {
+	return this.impl.AddRef()
+}
+
+func _ICoreWebView2ExecuteScriptCompletedHandlerIUnknownRelease(this *ICoreWebView2ExecuteScriptCompletedHandler) uintptr {
+	return this.impl.Release()
+}
+
+func _ICoreWebView2ExecuteScriptCompletedHandlerInvoke(this *ICoreWebView2ExecuteScriptCompletedHandler, errorCode uintptr, resultObjectAsJson *uint16) uintptr {
+	return this.impl.ExecuteScriptCompleted(errorCode, resultObjectAsJson)
+}
+
+type _ICoreWebView2ExecuteScriptCompletedHandlerImpl interface {
+	_IUnknownImpl
+	ExecuteScriptCompleted(errorCode uintptr, resultObjectAsJson *uint16) uintptr
+}
+
+var _ICoreWebView2ExecuteScriptCompletedHandlerFn = _ICoreWebView2ExecuteScriptCompletedHandlerVtbl{
+	_IUnknownVtbl{
+		NewComProc(_ICoreWebView2ExecuteScriptCompletedHandlerIUnknownQueryInterface),
+		NewComProc(_ICoreWebView2ExecuteScriptCompletedHandlerIUnknownAddRef),
+		NewComProc(_ICoreWebView2ExecuteScriptCompletedHandlerIUnknownRelease),
+	},
+	NewComProc(_ICoreWebView2ExecuteScriptCompletedHandlerInvoke),
+}
+
+func newICoreWebView2ExecuteScriptCompletedHandler(impl _ICoreWebView2ExecuteScriptCompletedHandlerImpl) *ICoreWebView2ExecuteScriptCompletedHandler {
+	return &ICoreWebView2ExecuteScriptCompletedHandler{
+		vtbl: &_ICoreWebView2ExecuteScriptCompletedHandlerFn,
+		impl: impl,
+	}
+}
+
+// executeScriptCompleted receives the result of a single ExecuteScript call.
+type executeScriptCompleted struct {
+	pendingHandler
+	handler *ICoreWebView2ExecuteScriptCompletedHandler
+	done    func(result string, err error)
+}
+
+func (a *executeScriptCompleted) QueryInterface(_, _ uintptr) uintptr {
+	return 0
+}
+
+func (a *executeScriptCompleted) ExecuteScriptCompleted(errorCode uintptr, resultObjectAsJson *uint16) uintptr {
+	a.complete()
+	if int32(errorCode) < 0 {
+		a.done("", windows.Errno(errorCode))
+		return 0
+	}
+	a.done(windows.UTF16PtrToString(resultObjectAsJson), nil)
+	return 0
+}
@@ -0,0 +1,43 @@
+package edge
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+type _ICoreWebView2NavigationStartingEventArgsVtbl struct {
+	_IUnknownVtbl
+	GetUri             ComProc
+	GetIsUserInitiated ComProc
+	GetIsRedirected    ComProc
+	GetRequestHeaders  ComProc
+	GetCancel          ComProc
+	PutCancel          ComProc
+	GetNavigationId    ComProc
+}
+
+type ICoreWebView2NavigationStartingEventArgs struct {
+	vtbl *_ICoreWebView2NavigationStartingEventArgsVtbl
+}
+
+func (i *ICoreWebView2NavigationStartingEventArgs) AddRef() uintptr {
+	r, _, _ := i.vtbl.AddRef.Call()
+	return r
+}
+
+func (i *ICoreWebView2NavigationStartingEventArgs) GetUri() (string, error) {
+	var err error
+	// Create *uint16 to hold result
+	var _uri *uint16
+	_, _, err = i.vtbl.GetUri.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&_uri)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return "", err
+	} // Get result and cleanup
+	uri := windows.UTF16PtrToString(_uri)
+	windows.CoTaskMemFree(unsafe.Pointer(_uri))
+	return uri, nil
+}
@@ -0,0 +1,34 @@
+package edge
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+type _ICoreWebView2ProcessFailedEventArgsVtbl struct {
+	_IUnknownVtbl
+	GetProcessFailedKind ComProc
+}
+
+type ICoreWebView2ProcessFailedEventArgs struct {
+	vtbl *_ICoreWebView2ProcessFailedEventArgsVtbl
+}
+
+func (i *ICoreWebView2ProcessFailedEventArgs) AddRef() uintptr {
+	r, _, _ := i.vtbl.AddRef.Call()
+	return r
+}
+
+func (i *ICoreWebView2ProcessFailedEventArgs) GetProcessFailedKind() (COREWEBVIEW2_PROCESS_FAILED_KIND, error) {
+	var err error
+	var kind COREWEBVIEW2_PROCESS_FAILED_KIND
+	_, _, err = i.vtbl.GetProcessFailedKind.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&kind)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return 0, err
+	}
+	return kind, nil
+}
@@ -4,9 +4,11 @@
 package edge
 
 import (
+	"errors"
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"unsafe"
 
@@ -14,6 +16,9 @@ import (
 	"golang.org/x/sys/windows"
 )
 
+// errClosed is returned by methods called after Close.
+var errClosed = errors.New("webview has been closed")
+
 type Chromium struct {
 	hwnd                  uintptr
 	focusOnInit           bool
@@ -26,21 +31,38 @@ type Chromium struct {
 	permissionRequested   *iCoreWebView2PermissionRequestedEventHandler
 	webResourceRequested  *iCoreWebView2WebResourceRequestedEventHandler
 	acceleratorKeyPressed *ICoreWebView2AcceleratorKeyPressedEventHandler
+	navigationStarting    *ICoreWebView2NavigationStartingEventHandler
 	navigationCompleted   *ICoreWebView2NavigationCompletedEventHandler
+	contentLoading        *ICoreWebView2ContentLoadingEventHandler
+	processFailed         *ICoreWebView2ProcessFailedEventHandler
 
 	environment *ICoreWebView2Environment
 
+	// m guards the state below that may be changed from any goroutine
+	m sync.Mutex
+
+	// Completion handlers that native code still holds a pointer to
+	pendingHandlers map[interface{}]struct{}
+
 	// Settings
 	DataPath string
 
+	// EchoMessages posts every string message received from the page back to
+	// it, as earlier versions always did.
+	EchoMessages bool
+
 	// permissions
 	permissions      map[CoreWebView2PermissionKind]CoreWebView2PermissionState
 	globalPermission *CoreWebView2PermissionState
 
 	// Callbacks
 	MessageCallback              func(string)
+	MessageReceivedCallback      func(sender *ICoreWebView2, args *ICoreWebView2WebMessageReceivedEventArgs)
 	WebResourceRequestedCallback func(request *ICoreWebView2WebResourceRequest, args *ICoreWebView2WebResourceRequestedEventArgs)
+	NavigationStartingCallback   func(sender *ICoreWebView2, args *ICoreWebView2NavigationStartingEventArgs)
 	NavigationCompletedCallback  func(sender *ICoreWebView2, args *ICoreWebView2NavigationCompletedEventArgs)
+	ContentLoadingCallback       func(sender *ICoreWebView2, args *ICoreWebView2ContentLoadingEventArgs)
+	ProcessFailedCallback        func(sender *ICoreWebView2, args *ICoreWebView2ProcessFailedEventArgs)
 	AcceleratorKeyCallback       func(uint) bool
 }
 
@@ -63,8 +85,12 @@ func NewChromium() *Chromium {
 	e.permissionRequested = newICoreWebView2PermissionRequestedEventHandler(e)
 	e.webResourceRequested = newICoreWebView2WebResourceRequestedEventHandler(e)
 	e.acceleratorKeyPressed = newICoreWebView2AcceleratorKeyPressedEventHandler(e)
+	e.navigationStarting = newICoreWebView2NavigationStartingEventHandler(e)
 	e.navigationCompleted = newICoreWebView2NavigationCompletedEventHandler(e)
+	e.contentLoading = newICoreWebView2ContentLoadingEventHandler(e)
+	e.processFailed = newICoreWebView2ProcessFailedEventHandler(e)
 	e.permissions = make(map[CoreWebView2PermissionKind]CoreWebView2PermissionState)
+	e.pendingHandlers = make(map[interface{}]struct{})
 
 	return e
 }
@@ -114,6 +140,9 @@ func (e *Chromium) Embed(hwnd uintptr) bool {
 }
 
 func (e *Chromium) Navigate(url string) {
+	if e.webview == nil {
+		return
+	}
 	_, _, _ = e.webview.vtbl.Navigate.Call(
 		uintptr(unsafe.Pointer(e.webview)),
 		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(url))),
@@ -121,6 +150,9 @@ func (e *Chromium) Navigate(url string) {
 }
 
 func (e *Chromium) NavigateToString(htmlContent string) {
+	if e.webview == nil {
+		return
+	}
 	_, _, _ = e.webview.vtbl.NavigateToString.Call(
 		uintptr(unsafe.Pointer(e.webview)),
 		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(htmlContent))),
@@ -128,6 +160,9 @@ func (e *Chromium) NavigateToString(htmlContent string) {
 }
 
 func (e *Chromium) Init(script string) {
+	if e.webview == nil {
+		return
+	}
 	_, _, _ = e.webview.vtbl.AddScriptToExecuteOnDocumentCreated.Call(
 		uintptr(unsafe.Pointer(e.webview)),
 		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(script))),
@@ -135,7 +170,41 @@ func (e *Chromium) Init(script string) {
 	)
 }
 
+// AddScriptToExecuteOnDocumentCreated is like Init, but once the script has
+// been added, done is called with the ID that can be used to remove it again
+// with RemoveScriptToExecuteOnDocumentCreated.
+func (e *Chromium) AddScriptToExecuteOnDocumentCreated(script string, done func(id string, err error)) {
+	if e.webview == nil {
+		done("", errClosed)
+		return
+	}
+	completed := &addScriptCompleted{done: done}
+	completed.pendingHandler = pendingHandler{chromium: e, self: completed}
+	completed.handler = newICoreWebView2AddScriptToExecuteOnDocumentCreatedCompletedHandler(completed)
+	e.keepHandler(completed)
+	if err := e.webview.AddScriptToExecuteOnDocumentCreated(script, completed.handler); err != nil {
+		e.releaseHandler(completed)
+		done("", err)
+	}
+}
+
+// RemoveScriptToExecuteOnDocumentCreated removes a script that was added with
+// AddScriptToExecuteOnDocumentCreated. Documents that are already loaded are
+// not affected.
+func (e *Chromium) RemoveScriptToExecuteOnDocumentCreated(id string) {
+	if e.webview == nil {
+		return
+	}
+	err := e.webview.RemoveScriptToExecuteOnDocumentCreated(id)
+	if err != nil {
+		log.Printf("Error removing script %s: %v", id, err)
+	}
+}
+
 func (e *Chromium) Eval(script string) {
+	if e.webview == nil {
+		return
+	}
 	_script, err := windows.UTF16PtrFromString(script)
 	if err != nil {
 		log.Fatal(err)
@@ -148,11 +217,122 @@ func (e *Chromium) Eval(script string) {
 	)
 }
 
+// PostWebMessageAsString posts a message to the page, which receives it as
+// the string data of a chrome.webview message event.
+func (e *Chromium) PostWebMessageAsString(webMessageAsString string) {
+	if e.webview == nil {
+		return
+	}
+	err := e.webview.PostWebMessageAsString(webMessageAsString)
+	if err != nil {
+		log.Printf("Error posting web message: %v", err)
+	}
+}
+
+// PostWebMessageAsJSON posts a message to the page, which receives it as the
+// parsed data of a chrome.webview message event.
+func (e *Chromium) PostWebMessageAsJSON(webMessageAsJSON string) {
+	if e.webview == nil {
+		return
+	}
+	err := e.webview.PostWebMessageAsJSON(webMessageAsJSON)
+	if err != nil {
+		log.Printf("Error posting web message: %v", err)
+	}
+}
+
+// ExecuteScript is like Eval, but once the script has run, done is called with
+// its result encoded as JSON.
+func (e *Chromium) ExecuteScript(script string, done func(result string, err error)) {
+	if e.webview == nil {
+		done("", errClosed)
+		return
+	}
+	completed := &executeScriptCompleted{done: done}
+	completed.pendingHandler = pendingHandler{chromium: e, self: completed}
+	completed.handler = newICoreWebView2ExecuteScriptCompletedHandler(completed)
+	e.keepHandler(completed)
+	if err := e.webview.ExecuteScript(script, completed.handler); err != nil {
+		e.releaseHandler(completed)
+		done("", err)
+	}
+}
+
+// keepHandler keeps a completion handler alive while native code holds a
+// pointer to it.
+func (e *Chromium) keepHandler(handler interface{}) {
+	e.m.Lock()
+	e.pendingHandlers[handler] = struct{}{}
+	e.m.Unlock()
+}
+
+func (e *Chromium) releaseHandler(handler interface{}) {
+	e.m.Lock()
+	delete(e.pendingHandlers, handler)
+	e.m.Unlock()
+}
+
+// pendingHandler implements the reference counting of a completion handler,
+// which stays in pendingHandlers until it has been invoked and native code
+// has released every reference to it.
+type pendingHandler struct {
+	chromium *Chromium
+	self     interface{}
+	refs     int32
+	invoked  int32
+}
+
+func (p *pendingHandler) AddRef() uintptr {
+	return uintptr(atomic.AddInt32(&p.refs, 1))
+}
+
+func (p *pendingHandler) Release() uintptr {
+	refs := atomic.AddInt32(&p.refs, -1)
+	if refs <= 0 && atomic.LoadInt32(&p.invoked) != 0 {
+		p.chromium.releaseHandler(p.self)
+	}
+	return uintptr(refs)
+}
+
+// complete is called when the handler is invoked.
+func (p *pendingHandler) complete() {
+	atomic.StoreInt32(&p.invoked, 1)
+	if atomic.LoadInt32(&p.refs) <= 0 {
+		p.chromium.releaseHandler(p.self)
+	}
+}
+
+// Close closes the controller, which stops the browser processes of the
+// webview once no other webview uses them, and releases the references that
+// the Chromium holds. Completion handlers that are still pending stay alive
+// until WebView2 invokes or releases them. Other methods do nothing, or
+// return an error, after Close.
+func (e *Chromium) Close() {
+	if e.controller == nil {
+		return
+	}
+	if err := e.controller.Close(); err != nil {
+		log.Printf("Error closing controller: %v", err)
+	}
+	_, _, _ = e.webview.vtbl.Release.Call(uintptr(unsafe.Pointer(e.webview)))
+	e.controller.Release()
+	if e.environment != nil {
+		_, _, _ = e.environment.vtbl.Release.Call(uintptr(unsafe.Pointer(e.environment)))
+	}
+	e.webview, e.controller, e.environment = nil, nil, nil
+}
+
 func (e *Chromium) Show() error {
+	if e.controller == nil {
+		return errClosed
+	}
 	return e.controller.PutIsVisible(true)
 }
 
 func (e *Chromium) Hide() error {
+	if e.controller == nil {
+		return errClosed
+	}
 	return e.controller.PutIsVisible(false)
 }
 
@@ -213,11 +393,26 @@ func (e *Chromium) CreateCoreWebView2ControllerCompleted(res uintptr, controller
 		uintptr(unsafe.Pointer(e.webResourceRequested)),
 		uintptr(unsafe.Pointer(&token)),
 	)
+	_, _, _ = e.webview.vtbl.AddNavigationStarting.Call(
+		uintptr(unsafe.Pointer(e.webview)),
+		uintptr(unsafe.Pointer(e.navigationStarting)),
+		uintptr(unsafe.Pointer(&token)),
+	)
 	_, _, _ = e.webview.vtbl.AddNavigationCompleted.Call(
 		uintptr(unsafe.Pointer(e.webview)),
 		uintptr(unsafe.Pointer(e.navigationCompleted)),
 		uintptr(unsafe.Pointer(&token)),
 	)
+	_, _, _ = e.webview.vtbl.AddContentLoading.Call(
+		uintptr(unsafe.Pointer(e.webview)),
+		uintptr(unsafe.Pointer(e.contentLoading)),
+		uintptr(unsafe.Pointer(&token)),
+	)
+	_, _, _ = e.webview.vtbl.AddProcessFailed.Call(
+		uintptr(unsafe.Pointer(e.webview)),
+		uintptr(unsafe.Pointer(e.processFailed)),
+		uintptr(unsafe.Pointer(&token)),
+	)
 
 	_ = e.controller.AddAcceleratorKeyPressed(e.acceleratorKeyPressed, &token)
 
@@ -230,7 +425,7 @@ func (e *Chromium) CreateCoreWebView2ControllerCompleted(res uintptr, controller
 	return 0
 }
 
-func (e *Chromium) MessageReceived(sender *ICoreWebView2, args *iCoreWebView2WebMessageReceivedEventArgs) uintptr {
+func (e *Chromium) MessageReceived(sender *ICoreWebView2, args *ICoreWebView2WebMessageReceivedEventArgs) uintptr {
 	var message *uint16
 	_, _, _ = args.vtbl.TryGetWebMessageAsString.Call(
 		uintptr(unsafe.Pointer(args)),
@@ -239,29 +434,39 @@ func (e *Chromium) MessageReceived(sender *ICoreWebView2, args *iCoreWebView2Web
 	if e.MessageCallback != nil {
 		e.MessageCallback(w32.Utf16PtrToString(message))
 	}
-	_, _, _ = sender.vtbl.PostWebMessageAsString.Call(
-		uintptr(unsafe.Pointer(sender)),
-		uintptr(unsafe.Pointer(message)),
-	)
+	if e.MessageReceivedCallback != nil {
+		e.MessageReceivedCallback(sender, args)
+	}
+	if e.EchoMessages {
+		_, _, _ = sender.vtbl.PostWebMessageAsString.Call(
+			uintptr(unsafe.Pointer(sender)),
+			uintptr(unsafe.Pointer(message)),
+		)
+	}
 	windows.CoTaskMemFree(unsafe.Pointer(message))
 	return 0
 }
 
 func (e *Chromium) SetPermission(kind CoreWebView2PermissionKind, state CoreWebView2PermissionState) {
+	e.m.Lock()
 	e.permissions[kind] = state
+	e.m.Unlock()
 }
 
 func (e *Chromium) SetGlobalPermission(state CoreWebView2PermissionState) {
+	e.m.Lock()
 	e.globalPermission = &state
+	e.m.Unlock()
 }
 
 func (e *Chromium) PermissionRequested(_ *ICoreWebView2, args *iCoreWebView2PermissionRequestedEventArgs) uintptr {
 	var kind CoreWebView2PermissionKind
 	_, _, _ = args.vtbl.GetPermissionKind.Call(
 		uintptr(unsafe.Pointer(args)),
-		uintptr(kind),
+		uintptr(unsafe.Pointer(&kind)),
 	)
 	var result CoreWebView2PermissionState
+	e.m.Lock()
 	if e.globalPermission != nil {
 		result = *e.globalPermission
 	} else {
@@ -271,6 +476,7 @@ func (e *Chromium) PermissionRequested(_ *ICoreWebView2, args *iCoreWebView2Perm
 			result = CoreWebView2PermissionStateDefault
 		}
 	}
+	e.m.Unlock()
 	_, _, _ = args.vtbl.PutState.Call(
 		uintptr(unsafe.Pointer(args)),
 		uintptr(result),
@@ -322,6 +528,9 @@ func (e *Chromium) AcceleratorKeyPressed(sender *ICoreWebView2Controller, args *
 }
 
 func (e *Chromium) GetSettings() (*ICoreWebViewSettings, error) {
+	if e.webview == nil {
+		return nil, errClosed
+	}
 	return e.webview.GetSettings()
 }
 
@@ -336,6 +545,27 @@ func boolToInt(input bool) int {
 	return 0
 }
 
+func (e *Chromium) NavigationStarting(sender *ICoreWebView2, args *ICoreWebView2NavigationStartingEventArgs) uintptr {
+	if e.NavigationStartingCallback != nil {
+		e.NavigationStartingCallback(sender, args)
+	}
+	return 0
+}
+
+func (e *Chromium) ContentLoading(sender *ICoreWebView2, args *ICoreWebView2ContentLoadingEventArgs) uintptr {
+	if e.ContentLoadingCallback != nil {
+		e.ContentLoadingCallback(sender, args)
+	}
+	return 0
+}
+
+func (e *Chromium) ProcessFailed(sender *ICoreWebView2, args *ICoreWebView2ProcessFailedEventArgs) uintptr {
+	if e.ProcessFailedCallback != nil {
+		e.ProcessFailedCallback(sender, args)
+	}
+	return 0
+}
+
 func (e *Chromium) NavigationCompleted(sender *ICoreWebView2, args *ICoreWebView2NavigationCompletedEventArgs) uintptr {
 	if e.NavigationCompletedCallback != nil {
 		e.NavigationCompletedCallback(sender, args)
@@ -151,6 +151,52 @@ func (i *ICoreWebView2) GetSettings() (*ICoreWebViewSettings, error) {
 	return settings, nil
 }
 
+func (i *ICoreWebView2) PostWebMessageAsJSON(webMessageAsJSON string) error {
+	_json, err := windows.UTF16PtrFromString(webMessageAsJSON)
+	if err != nil {
+		return err
+	}
+	_, _, err = i.vtbl.PostWebMessageAsJSON.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(_json)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return err
+	}
+	return nil
+}
+
+func (i *ICoreWebView2) ExecuteScript(javaScript string, handler *ICoreWebView2ExecuteScriptCompletedHandler) error {
+	_javaScript, err := windows.UTF16PtrFromString(javaScript)
+	if err != nil {
+		return err
+	}
+	_, _, err = i.vtbl.ExecuteScript.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(_javaScript)),
+		uintptr(unsafe.Pointer(handler)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return err
+	}
+	return nil
+}
+
+func (i *ICoreWebView2) PostWebMessageAsString(webMessageAsString string) error {
+	_message, err := windows.UTF16PtrFromString(webMessageAsString)
+	if err != nil {
+		return err
+	}
+	_, _, err = i.vtbl.PostWebMessageAsString.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(_message)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return err
+	}
+	return nil
+}
+
 // ICoreWebView2Environment
 
 type iCoreWebView2EnvironmentVtbl struct {
@@ -213,10 +259,60 @@ type iCoreWebView2WebMessageReceivedEventArgsVtbl struct {
 	TryGetWebMessageAsString ComProc
 }
 
-type iCoreWebView2WebMessageReceivedEventArgs struct {
+type ICoreWebView2WebMessageReceivedEventArgs struct {
 	vtbl *iCoreWebView2WebMessageReceivedEventArgsVtbl
 }
 
+// GetSource returns the URI of the document that sent the message.
+func (i *ICoreWebView2WebMessageReceivedEventArgs) GetSource() (string, error) {
+	var err error
+	// Create *uint16 to hold result
+	var _source *uint16
+	_, _, err = i.vtbl.GetSource.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&_source)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return "", err
+	} // Get result and cleanup
+	source := windows.UTF16PtrToString(_source)
+	windows.CoTaskMemFree(unsafe.Pointer(_source))
+	return source, nil
+}
+
+// GetWebMessageAsJSON returns the message encoded as JSON.
+func (i *ICoreWebView2WebMessageReceivedEventArgs) GetWebMessageAsJSON() (string, error) {
+	var err error
+	// Create *uint16 to hold result
+	var _message *uint16
+	_, _, err = i.vtbl.GetWebMessageAsJSON.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&_message)),
+	)
+	if err != windows.ERROR_SUCCESS {
+		return "", err
+	} // Get result and cleanup
+	message := windows.UTF16PtrToString(_message)
+	windows.CoTaskMemFree(unsafe.Pointer(_message))
+	return message, nil
+}
+
+// TryGetWebMessageAsString returns the message if it is a string. ok is
+// false if the message is any other kind of value.
+func (i *ICoreWebView2WebMessageReceivedEventArgs) TryGetWebMessageAsString() (message string, ok bool) {
+	var _message *uint16
+	hr, _, _ := i.vtbl.TryGetWebMessageAsString.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&_message)),
+	)
+	if int32(hr) < 0 {
+		return "", false
+	}
+	message = windows.UTF16PtrToString(_message)
+	windows.CoTaskMemFree(unsafe.Pointer(_message))
+	return message, true
+}
+
 // ICoreWebView2PermissionRequestedEventArgs
 
 type iCoreWebView2PermissionRequestedEventArgsVtbl struct {
@@ -286,7 +382,7 @@ func newICoreWebView2CreateCoreWebView2EnvironmentCompletedHandler(impl iCoreWeb
 
 type iCoreWebView2WebMessageReceivedEventHandlerImpl interface {
 	_IUnknownImpl
-	MessageReceived(sender *ICoreWebView2, args *iCoreWebView2WebMessageReceivedEventArgs) uintptr
+	MessageReceived(sender *ICoreWebView2, args *ICoreWebView2WebMessageReceivedEventArgs) uintptr
 }
 
 type iCoreWebView2WebMessageReceivedEventHandlerVtbl struct {
@@ -311,7 +407,7 @@ func _ICoreWebView2WebMessageReceivedEventHandlerIUnknownRelease(this *iCoreWebV
 	return this.impl.Release()
 }
 
-func _ICoreWebView2WebMessageReceivedEventHandlerInvoke(this *iCoreWebView2WebMessageReceivedEventHandler, sender *ICoreWebView2, args *iCoreWebView2WebMessageReceivedEventArgs) uintptr {
+func _ICoreWebView2WebMessageReceivedEventHandlerInvoke(this *iCoreWebView2WebMessageReceivedEventHandler, sender *ICoreWebView2, args *ICoreWebView2WebMessageReceivedEventArgs) uintptr {
 	return this.impl.MessageReceived(sender, args)
 }
 
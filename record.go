@@ -0,0 +1,243 @@
+package webview2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Record is a call to a bound function and its outcome, as written to
+// WebViewOptions.RecordCalls, one per line.
+type Record struct {
+	Time   time.Time         `json:"time"`
+	Source string            `json:"source,omitempty"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+
+	// Result is the result encoded with the codec of the webview. It is
+	// empty if the call failed.
+	Result json.RawMessage `json:"result,omitempty"`
+
+	// Error is the error that the JavaScript promise was rejected with, or
+	// nil if the call succeeded.
+	Error *RecordedError `json:"error,omitempty"`
+}
+
+// RecordedError is an error returned by a bound function, as it is sent to
+// JavaScript.
+type RecordedError struct {
+	Message string          `json:"message"`
+	Code    *int            `json:"code,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func newRecordedError(err error) *RecordedError {
+	e := &RecordedError{}
+	b, _ := json.Marshal(newRPCError(err))
+	_ = json.Unmarshal(b, e)
+	return e
+}
+
+func (e *RecordedError) String() string {
+	if e.Code == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (code %d)", e.Message, *e.Code)
+}
+
+// newRecord returns the record of call d, with its result encoded by codec
+// as it would be sent to the page.
+func newRecord(codec Codec, d rpcMessage, res interface{}, err error) Record {
+	r := Record{Time: time.Now(), Source: d.Source, Method: d.Method, Params: d.Params}
+	if err == nil {
+		r.Result, err = codec.Marshal(res)
+	}
+	if err != nil {
+		r.Result, r.Error = nil, newRecordedError(err)
+	}
+	return r
+}
+
+// recorder writes records to the writer given as WebViewOptions.RecordCalls.
+type recorder struct {
+	m   sync.Mutex
+	enc *json.Encoder
+}
+
+func newRecorder(out io.Writer) *recorder {
+	return &recorder{enc: json.NewEncoder(out)}
+}
+
+func (r *recorder) record(codec Codec, d rpcMessage, res interface{}, err error) {
+	rec := newRecord(codec, d, res, err)
+	r.m.Lock()
+	defer r.m.Unlock()
+	if err := r.enc.Encode(rec); err != nil {
+		log.Printf("failed to record call to %s: %v", d.Method, err)
+	}
+}
+
+// Replayer calls bound functions with calls recorded from a webview, without
+// a browser, and reports where the outcome differs from the recording. It
+// does not depend on WebView2, so it can be used in tests on any platform:
+//
+//	r := webview2.NewReplayer()
+//	r.Bind("add", app.Add)
+//	mismatches, err := r.Replay(context.Background(), f)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	for _, m := range mismatches {
+//		t.Error(m)
+//	}
+//
+// Functions are bound as with WebView.Bind. Calls are made one at a time, in
+// the order they were recorded, on the calling goroutine, regardless of the
+// binding mode.
+type Replayer struct {
+	// Codec decodes the recorded arguments and encodes the new results. It
+	// must match the codec the calls were recorded with. If nil, JSONCodec
+	// is used.
+	Codec Codec
+
+	bindings   map[string]*binding
+	middleware []Middleware
+}
+
+// NewReplayer returns a Replayer with no bound functions.
+func NewReplayer() *Replayer {
+	return &Replayer{bindings: map[string]*binding{}}
+}
+
+// Bind binds f under name, as WebView.Bind does.
+func (r *Replayer) Bind(name string, f interface{}) error {
+	return r.BindWithOptions(name, f, BindOptions{})
+}
+
+// BindWithOptions binds f under name, as WebView.BindWithOptions does.
+func (r *Replayer) BindWithOptions(name string, f interface{}, opts BindOptions) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := checkFunc(f); err != nil {
+		return err
+	}
+	if err := checkOptions(opts); err != nil {
+		return err
+	}
+	r.bindings[name] = newBinding(f, opts, nil)
+	return nil
+}
+
+// BindObject binds the methods of obj, as WebView.BindObject does.
+func (r *Replayer) BindObject(namespace string, obj interface{}) error {
+	return r.BindObjectWithOptions(namespace, obj, BindOptions{})
+}
+
+// BindObjectWithOptions binds the methods of obj, as
+// WebView.BindObjectWithOptions does.
+func (r *Replayer) BindObjectWithOptions(namespace string, obj interface{}, opts BindOptions) error {
+	if err := checkName(namespace); err != nil {
+		return err
+	}
+	methods, err := objectMethods(obj, opts.NameCase)
+	if err != nil {
+		return err
+	}
+	for name, f := range methods {
+		if err := r.BindWithOptions(namespace+"."+name, f, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Use adds middleware, as WebView.Use does.
+func (r *Replayer) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Replay reads records from in and makes each call again, passing ctx to
+// functions that accept a context. It returns a Mismatch for every call
+// whose result or error differs from the recorded one. The error is only
+// non-nil if in cannot be read.
+func (r *Replayer) Replay(ctx context.Context, in io.Reader) ([]Mismatch, error) {
+	codec := r.Codec
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	middleware := append([]Middleware{Recover}, r.middleware...)
+
+	var mismatches []Mismatch
+	dec := json.NewDecoder(in)
+	for line := 1; ; line++ {
+		var rec Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			return mismatches, nil
+		} else if err != nil {
+			return mismatches, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		d := rpcMessage{ID: line, Method: rec.Method, Params: rec.Params, Source: rec.Source}
+		res, err := callbinding(ctx, codec, middleware, nil, r.bindings[rec.Method], d)
+		var got Record
+		settle(d, res, err, func(res interface{}, err error) {
+			got = newRecord(codec, d, res, err)
+		})
+		if !equalJSON(rec.Result, got.Result) || !equalErrors(rec.Error, got.Error) {
+			mismatches = append(mismatches, Mismatch{Line: line, Record: rec, Result: got.Result, Error: got.Error})
+		}
+	}
+}
+
+// Mismatch is a replayed call whose outcome differs from the recording.
+type Mismatch struct {
+	// Line is the line of the record in the replayed input, from 1.
+	Line int
+
+	// Record is the recorded call.
+	Record Record
+
+	// Result and Error are the outcome of the replayed call.
+	Result json.RawMessage
+	Error  *RecordedError
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("line %d: %s: recorded %s, got %s", m.Line, m.Record.Method, outcome(m.Record.Result, m.Record.Error), outcome(m.Result, m.Error))
+}
+
+func outcome(result json.RawMessage, err *RecordedError) string {
+	if err != nil {
+		return "error " + err.String()
+	}
+	return string(result)
+}
+
+// equalJSON reports whether a and b encode the same value, ignoring
+// formatting and the order of object keys.
+func equalJSON(a, b json.RawMessage) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func equalErrors(a, b *RecordedError) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if (a.Code == nil) != (b.Code == nil) || (a.Code != nil && *a.Code != *b.Code) {
+		return false
+	}
+	return a.Message == b.Message && equalJSON(a.Data, b.Data)
+}
@@ -0,0 +1,157 @@
+package webview2
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+type recordUser struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+type recordUsers struct{}
+
+func (recordUsers) Find(name string) (*recordUser, error) {
+	if name == "cat" {
+		return &recordUser{Name: name}, nil
+	}
+	return nil, &RPCError{Code: 404, Message: "no such user", Data: map[string]string{"name": name}}
+}
+
+func TestReplay(t *testing.T) {
+	// Recover logs the stack of the panic that is replayed.
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	r := NewReplayer()
+	if err := r.Bind("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Bind("crash", func() int { return []int{}[1] }); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.BindObjectWithOptions("user", recordUsers{}, BindOptions{NameCase: NameCaseCamel}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open("testdata/calls.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	mismatches, err := r.Replay(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`line 2: add: recorded 5, got 4`,
+		`line 4: add: recorded 6, got error function arguments mismatch (code -32602)`,
+		`line 5: crash: recorded error out of range, got error runtime error: index out of range [1] with length 0`,
+		`line 7: user.find: recorded error no such user (code 410), got error no such user (code 404)`,
+	}
+	if len(mismatches) != len(want) {
+		t.Fatalf("got %d mismatches, want %d: %v", len(mismatches), len(want), mismatches)
+	}
+	for i, m := range mismatches {
+		if m.String() != want[i] {
+			t.Errorf("mismatch %d: got %q, want %q", i, m, want[i])
+		}
+	}
+	if m := mismatches[0]; m.Record.Source != "https://app.example.com/" || string(m.Result) != "4" || m.Error != nil {
+		t.Errorf("mismatch 0: got %+v", m)
+	}
+}
+
+type panicky struct{}
+
+func (*panicky) UnmarshalJSON([]byte) error {
+	panic("bad input")
+}
+
+func TestReplayDecodePanic(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	r := NewReplayer()
+	if err := r.Bind("take", func(p panicky) {}); err != nil {
+		t.Fatal(err)
+	}
+	in := `{"method":"take","params":[{}],"error":{"message":"bad input","code":-32602}}`
+	mismatches, err := r.Replay(context.Background(), strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("unexpected mismatches: %v", mismatches)
+	}
+}
+
+type panickyResult struct{}
+
+func (panickyResult) MarshalJSON() ([]byte, error) {
+	panic("bad output")
+}
+
+func TestReplayEncodePanic(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	r := NewReplayer()
+	if err := r.Bind("give", func() panickyResult { return panickyResult{} }); err != nil {
+		t.Fatal(err)
+	}
+	in := `{"method":"give","params":[],"error":{"message":"bad output"}}`
+	mismatches, err := r.Replay(context.Background(), strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("unexpected mismatches: %v", mismatches)
+	}
+}
+
+func TestReplayMiddleware(t *testing.T) {
+	r := NewReplayer()
+	if err := r.Bind("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	var methods []string
+	r.Use(func(next Handler) Handler {
+		return func(call *Call) (interface{}, error) {
+			methods = append(methods, call.Method)
+			if call.Source != "https://app.example.com/" {
+				return nil, errors.New("forbidden")
+			}
+			return next(call)
+		}
+	})
+
+	in := `{"source":"https://app.example.com/","method":"add","params":[1,2],"result":3}
+{"source":"https://evil.example/","method":"add","params":[1,2],"error":{"message":"forbidden"}}
+`
+	mismatches, err := r.Replay(context.Background(), strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("unexpected mismatches: %v", mismatches)
+	}
+	if len(methods) != 2 {
+		t.Errorf("middleware saw %v, want two calls", methods)
+	}
+}
+
+func TestReplayInvalidInput(t *testing.T) {
+	r := NewReplayer()
+	_, err := r.Replay(context.Background(), strings.NewReader(`{"method":"add","params":[]}`+"\nnot json\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Errorf("got error %v, want one for line 2", err)
+	}
+}
@@ -0,0 +1,364 @@
+package webview2
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+// tsTypesNamespace is the namespace that Go struct types are declared in, so
+// that they cannot merge with global types of the same name, such as Event.
+const tsTypesNamespace = "go"
+
+// WriteTypeScript writes TypeScript declarations for functions that are bound
+// under the names they are keyed by in bindings. Each function is declared
+// as a global that returns a Promise of its result, and every Go struct type
+// used in a signature is declared as an interface in the go namespace, such
+// as go.User, whose fields follow the same json struct tags that
+// encoding/json does. Functions with dotted names, such as those bound by
+// BindObject, are declared in namespaces. A function whose first parameter
+// is a context.Context is declared with a trailing optional AbortSignal
+// parameter, which cancels the context when aborted.
+//
+// A name that is not a valid identifier, such as a reserved word, is only
+// declared as a property of Window. It is an error for a dotted name to
+// contain such a part, since it cannot be declared in a namespace.
+//
+// The output is a global declaration file, suitable for saving as a .d.ts
+// file in the frontend project.
+func WriteTypeScript(out io.Writer, bindings map[string]interface{}) error {
+	names := make([]string, 0, len(bindings))
+	for name, f := range bindings {
+		if reflect.TypeOf(f) == nil || reflect.TypeOf(f).Kind() != reflect.Func {
+			return errors.New("binding " + name + " is not a function")
+		}
+		if strings.Contains(name, ".") && !isIdentifierPath(name) {
+			return errors.New("binding " + name + " cannot be declared in TypeScript: every part of a dotted name must be an identifier")
+		}
+		names = append(names, name)
+	}
+	// Sort by namespace first, so that each namespace is declared once.
+	sort.Slice(names, func(i, j int) bool {
+		nsi, nsj := names[i][:strings.LastIndex(names[i], ".")+1], names[j][:strings.LastIndex(names[j], ".")+1]
+		if nsi != nsj {
+			return nsi < nsj
+		}
+		return names[i] < names[j]
+	})
+
+	g := &tsGenerator{names: map[reflect.Type]string{}, used: map[string]bool{}}
+	var funcs strings.Builder
+	namespace := ""
+	for _, name := range names {
+		ns, fn := "", name
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			ns, fn = name[:i], name[i+1:]
+		}
+		if ns != namespace {
+			if namespace != "" {
+				funcs.WriteString("}\n")
+			}
+			if ns != "" {
+				funcs.WriteString("declare namespace " + ns + " {\n")
+			}
+			namespace = ns
+		}
+
+		sig := g.signature(reflect.TypeOf(bindings[name]))
+		switch {
+		case ns != "":
+			funcs.WriteString("\tfunction " + fn + sig + ";\n")
+		case isIdentifier(name):
+			funcs.WriteString("declare function " + name + sig + ";\n")
+		default:
+			// Names that are not valid identifiers are only reachable
+			// through the window object.
+			funcs.WriteString("interface Window {\n\t" + strconv.Quote(name) + sig + ";\n}\n")
+		}
+	}
+	if namespace != "" {
+		funcs.WriteString("}\n")
+	}
+
+	bw := bufio.NewWriter(out)
+	bw.WriteString("// Code generated by go-webview2. DO NOT EDIT.\n")
+	if len(g.queue) > 0 {
+		bw.WriteString("\ndeclare namespace " + tsTypesNamespace + " {\n")
+		for i := 0; i < len(g.queue); i++ {
+			// Declaring an interface may queue more struct types.
+			if i > 0 {
+				bw.WriteString("\n")
+			}
+			bw.WriteString(g.declare(g.queue[i]))
+		}
+		bw.WriteString("}\n")
+	}
+	if len(names) > 0 {
+		bw.WriteString("\n" + funcs.String())
+	}
+	return bw.Flush()
+}
+
+// tsGenerator maps Go types to TypeScript types, collecting the named struct
+// types that need to be declared as interfaces.
+type tsGenerator struct {
+	names map[reflect.Type]string
+	used  map[string]bool
+	queue []reflect.Type
+}
+
+// signature returns the parameter list and return type of function type t.
+// A function that takes a context also takes an optional AbortSignal, unless
+// it is variadic, since nothing can follow a rest parameter.
+func (g *tsGenerator) signature(t reflect.Type) string {
+	types, result := g.types(newBindingInfo("", t, BindOptions{}))
+	params := make([]string, len(types))
+	for i, typ := range types {
+		params[i] = "arg" + strconv.Itoa(i) + ": " + typ
+		if t.IsVariadic() && i == len(types)-1 {
+			params[i] = "..." + params[i]
+		}
+	}
+	if t.NumIn() > 0 && t.In(0) == contextType && !t.IsVariadic() {
+		params = append(params, "signal?: AbortSignal")
+	}
+	return "(" + strings.Join(params, ", ") + "): Promise<" + result + ">"
+}
+
+// types returns the TypeScript types of the parameters and of the result of
+// a bound function. A variadic parameter has an array type.
+func (g *tsGenerator) types(info BindingInfo) (params []string, result string) {
+	params = make([]string, len(info.Params))
+	for i, p := range info.Params {
+		if info.Variadic && i == len(info.Params)-1 {
+			params[i] = g.arrayOf(p.Elem())
+		} else {
+			params[i] = g.typeOf(p)
+		}
+	}
+	result = "void"
+	if info.Result != nil {
+		result = g.typeOf(info.Result)
+	}
+	return params, result
+}
+
+// bindingManifest is how a bound function is described to the page in
+// window.go.__bindings.
+type bindingManifest struct {
+	Params   []string `json:"params"`
+	Result   string   `json:"result"`
+	Variadic bool     `json:"variadic"`
+}
+
+func newBindingManifest(info BindingInfo) bindingManifest {
+	g := &tsGenerator{names: map[reflect.Type]string{}, used: map[string]bool{}}
+	params, result := g.types(info)
+	return bindingManifest{Params: params, Result: result, Variadic: info.Variadic}
+}
+
+func (g *tsGenerator) typeOf(t reflect.Type) string {
+	if t == timeType {
+		return "string"
+	}
+	if t == jsFuncType {
+		return "(...args: any[]) => void"
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return "any"
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Ptr:
+		return g.typeOf(t.Elem()) + " | null"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes byte slices as base64 strings.
+			return "string"
+		}
+		return g.arrayOf(t.Elem()) + " | null"
+	case reflect.Array:
+		return g.arrayOf(t.Elem())
+	case reflect.Map:
+		return "Record<string, " + g.typeOf(t.Elem()) + ">"
+	case reflect.Struct:
+		if t.Name() == "" {
+			fields := g.fields(t)
+			if len(fields) == 0 {
+				return "{}"
+			}
+			return "{ " + strings.Join(fields, " ") + " }"
+		}
+		return g.nameOf(t)
+	default:
+		return "any"
+	}
+}
+
+func (g *tsGenerator) arrayOf(elem reflect.Type) string {
+	s := g.typeOf(elem)
+	if strings.ContainsAny(s, " |") {
+		return "(" + s + ")[]"
+	}
+	return s + "[]"
+}
+
+// nameOf returns the qualified interface name used for the named struct type
+// t, queueing it for declaration the first time it is seen.
+func (g *tsGenerator) nameOf(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return tsTypesNamespace + "." + name
+	}
+	name := tsIdentifier(t.Name())
+	if tsReserved[name] || tsPredefined[name] {
+		name += "_"
+	}
+	if g.used[name] {
+		// Disambiguate structs with the same name from different packages.
+		pkg := t.PkgPath()
+		name = tsIdentifier(pkg[strings.LastIndex(pkg, "/")+1:]) + "_" + name
+		for base, i := name, 2; g.used[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+	}
+	g.names[t] = name
+	g.used[name] = true
+	g.queue = append(g.queue, t)
+	return tsTypesNamespace + "." + name
+}
+
+func (g *tsGenerator) declare(t reflect.Type) string {
+	var b strings.Builder
+	b.WriteString("\tinterface " + g.names[t] + " {\n")
+	for _, field := range g.fields(t) {
+		b.WriteString("\t\t" + field + "\n")
+	}
+	b.WriteString("\t}\n")
+	return b.String()
+}
+
+// fields returns the property declarations for the JSON object that struct
+// type t is encoded as.
+func (g *tsGenerator) fields(t reflect.Type) []string {
+	fields := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i:]
+		}
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			// Fields of untagged embedded structs are promoted, as with
+			// encoding/json.
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, g.fields(ft)...)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		typ := g.typeOf(f.Type)
+		if strings.Contains(opts, ",string") {
+			typ = "string"
+		}
+		optional := ""
+		if strings.Contains(opts, ",omitempty") {
+			optional = "?"
+		}
+		if !isPropertyName(name) {
+			name = strconv.Quote(name)
+		}
+		fields = append(fields, name+optional+": "+typ+";")
+	}
+	return fields
+}
+
+// isIdentifier reports whether s can name a declared function or namespace.
+func isIdentifier(s string) bool {
+	return isPropertyName(s) && !tsReserved[s]
+}
+
+// isPropertyName reports whether s can name a property without quotes, which
+// unlike a declaration may be a reserved word.
+func isPropertyName(s string) bool {
+	return s != "" && tsIdentifier(s) == s && !(s[0] >= '0' && s[0] <= '9')
+}
+
+// tsReserved holds the words that cannot name a declaration in TypeScript,
+// including those only reserved in strict mode.
+var tsReserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true,
+	"import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true,
+	"true": true, "try": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true, "implements": true, "interface": true,
+	"let": true, "package": true, "private": true, "protected": true,
+	"public": true, "static": true, "yield": true, "await": true,
+	"arguments": true, "eval": true,
+}
+
+// tsPredefined holds the predefined types, which cannot name an interface.
+var tsPredefined = map[string]bool{
+	"any": true, "bigint": true, "boolean": true, "never": true, "number": true,
+	"object": true, "string": true, "symbol": true, "undefined": true,
+	"unknown": true,
+}
+
+func isIdentifierPath(s string) bool {
+	for _, part := range strings.Split(s, ".") {
+		if !isIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func tsIdentifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,142 @@
+package webview2
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type tsBase struct {
+	ID      int    `json:"id"`
+	Created string `json:"created,omitempty"`
+}
+
+type tsUser struct {
+	tsBase
+	Name     string         `json:"name"`
+	Email    string         `json:"email,omitempty"`
+	Age      int            `json:"age,string"`
+	Avatar   []byte         `json:"avatar"`
+	Manager  *tsUser        `json:"manager"`
+	Tags     []string       `json:"tags"`
+	Meta     map[string]int `json:"meta"`
+	Secret   string         `json:"-"`
+	Untagged bool
+	Dashed   string `json:"x-dashed"`
+	Delete   bool   `json:"delete"`
+	internal string
+	Extra    *struct{ N int }  `json:"extra"`
+	Labels   map[string]string `json:",omitempty"`
+}
+
+// Event has the name of a DOM type, which it must not merge with.
+type Event struct {
+	Kind string `json:"kind"`
+}
+
+func writeTypeScript(t *testing.T, bindings map[string]interface{}) string {
+	t.Helper()
+	var b strings.Builder
+	if err := WriteTypeScript(&b, bindings); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestWriteTypeScript(t *testing.T) {
+	got := writeTypeScript(t, map[string]interface{}{
+		"getUser": func(ctx context.Context, id int) (*tsUser, error) { return nil, nil },
+		"emit":    func(e Event) {},
+	})
+	want := `// Code generated by go-webview2. DO NOT EDIT.
+
+declare namespace go {
+	interface Event {
+		kind: string;
+	}
+
+	interface tsUser {
+		id: number;
+		created?: string;
+		name: string;
+		email?: string;
+		age: string;
+		avatar: string;
+		manager: go.tsUser | null;
+		tags: string[] | null;
+		meta: Record<string, number>;
+		Untagged: boolean;
+		"x-dashed": string;
+		delete: boolean;
+		extra: { N: number; } | null;
+		Labels?: Record<string, string>;
+	}
+}
+
+declare function emit(arg0: go.Event): Promise<void>;
+declare function getUser(arg0: number, signal?: AbortSignal): Promise<go.tsUser | null>;
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteTypeScriptSignatures(t *testing.T) {
+	tests := []struct {
+		name string
+		f    interface{}
+		want string
+	}{
+		{"sum", func(xs ...int) int { return 0 }, "declare function sum(...arg0: number[]): Promise<number>;"},
+		{"join", func(sep string, parts ...*string) string { return "" }, "declare function join(arg0: string, ...arg1: (string | null)[]): Promise<string>;"},
+		{"bytes", func(b []byte) ([]byte, error) { return nil, nil }, "declare function bytes(arg0: string): Promise<string>;"},
+		{"progress", func(f *JSFunc) error { return nil }, "declare function progress(arg0: (...args: any[]) => void): Promise<void>;"},
+		{"wait", func(ctx context.Context) error { return nil }, "declare function wait(signal?: AbortSignal): Promise<void>;"},
+		{"fetch", func(ctx context.Context, url string) (string, error) { return "", nil }, "declare function fetch(arg0: string, signal?: AbortSignal): Promise<string>;"},
+		{"max", func(ctx context.Context, xs ...int) int { return 0 }, "declare function max(...arg0: number[]): Promise<number>;"},
+		{"delete", func() {}, "interface Window {\n\t\"delete\"(): Promise<void>;\n}"},
+		{"my-func", func() {}, "interface Window {\n\t\"my-func\"(): Promise<void>;\n}"},
+	}
+	for _, tt := range tests {
+		got := writeTypeScript(t, map[string]interface{}{tt.name: tt.f})
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%s: got:\n%s\nwant it to contain:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTypeScriptNamespaces(t *testing.T) {
+	got := writeTypeScript(t, map[string]interface{}{
+		"app.quit":         func() {},
+		"app.files.open":   func(path string) error { return nil },
+		"app.files.remove": func(path string) error { return nil },
+		"version":          func() string { return "" },
+	})
+	want := `// Code generated by go-webview2. DO NOT EDIT.
+
+declare function version(): Promise<string>;
+declare namespace app {
+	function quit(): Promise<void>;
+}
+declare namespace app.files {
+	function open(arg0: string): Promise<void>;
+	function remove(arg0: string): Promise<void>;
+}
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteTypeScriptErrors(t *testing.T) {
+	for _, bindings := range []map[string]interface{}{
+		{"notAFunc": 42},
+		{"app.delete": func() {}},
+		{"default.open": func() {}},
+		{"app.my-func": func() {}},
+	} {
+		if err := WriteTypeScript(&strings.Builder{}, bindings); err == nil {
+			t.Errorf("%v: expected an error", bindings)
+		}
+	}
+}
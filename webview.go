@@ -4,12 +4,21 @@
 package webview2
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"reflect"
+	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/jchv/go-webview2/internal/w32"
@@ -21,6 +30,10 @@ import (
 var (
 	windowContext     = map[uintptr]interface{}{}
 	windowContextSync sync.RWMutex
+
+	// The window class is registered once for all windows, since every
+	// registration also uses up a callback slot.
+	registerClassOnce sync.Once
 )
 
 func getWindowContext(wnd uintptr) interface{} {
@@ -35,27 +48,72 @@ func setWindowContext(wnd uintptr, data interface{}) {
 	windowContext[wnd] = data
 }
 
+func deleteWindowContext(wnd uintptr) {
+	windowContextSync.Lock()
+	defer windowContextSync.Unlock()
+	delete(windowContext, wnd)
+}
+
 type browser interface {
 	Embed(hwnd uintptr) bool
 	Resize()
 	Navigate(url string)
 	NavigateToString(htmlContent string)
 	Init(script string)
+	AddScriptToExecuteOnDocumentCreated(script string, done func(id string, err error))
+	RemoveScriptToExecuteOnDocumentCreated(id string)
 	Eval(script string)
+	ExecuteScript(script string, done func(result string, err error))
+	PostWebMessageAsJSON(webMessageAsJSON string)
+	PostWebMessageAsString(webMessageAsString string)
 	NotifyParentWindowPositionChanged() error
 	Focus()
+	Close()
 }
 
 type webview struct {
-	hwnd       uintptr
-	mainthread uintptr
-	browser    browser
-	autofocus  bool
-	maxsz      w32.Point
-	minsz      w32.Point
-	m          sync.Mutex
-	bindings   map[string]interface{}
-	dispatchq  []func()
+	hwnd           uintptr
+	mainthread     uintptr
+	browser        browser
+	autofocus      bool
+	maxsz          w32.Point
+	minsz          w32.Point
+	m              sync.Mutex
+	bindings       map[string]*binding
+	middleware     []Middleware
+	allowedOrigins []string
+	msghandlers    []func(source string, msg json.RawMessage)
+	jsonrpc        bool
+	codec          Codec
+	recorder       *recorder
+	pool           *workqueue
+	dispatchq      []func()
+	timers         map[uintptr]*timer
+	nexttimer      uintptr
+
+	// ctx is cancelled when the window is destroyed; pagectx is derived from
+	// it and is replaced every time a navigation commits, so that calls from
+	// a page keep running if a navigation away from it is cancelled or fails
+	// before a new document replaces it.
+	ctx        context.Context
+	cancel     context.CancelFunc
+	pagectx    context.Context
+	pagecancel context.CancelFunc
+	calls      map[rpcKey]*rpcCall
+
+	// runerr is returned by RunContext once the main loop has stopped.
+	runerr error
+
+	// Lifecycle hooks. app is the App that owns the window, if any. ready is
+	// set once the ready hooks have been called, and destroying once Destroy
+	// has been called.
+	app            *App
+	ready          bool
+	destroying     bool
+	readyhooks     []func()
+	closehooks     []func() bool
+	closedhooks    []func()
+	destroyedhooks []func()
 }
 
 type WindowOptions struct {
@@ -81,6 +139,38 @@ type WebViewOptions struct {
 	// WindowOptions customizes the window that is created to embed the
 	// WebView2 widget.
 	WindowOptions WindowOptions
+
+	// BindingWorkers limits how many calls to bound functions may run at once
+	// off the UI thread. If zero, runtime.NumCPU() is used.
+	BindingWorkers int
+
+	// AllowedOrigins lists the origins of pages that may call bound functions,
+	// such as "https://app.example.com" or "https://*.example.com". Calls from
+	// other pages are rejected, and window.go and the bound functions are not
+	// defined in them. Pages loaded with SetHtml have the origin "about:". If
+	// empty, every page may call bound functions.
+	AllowedOrigins []string
+
+	// JSONRPC lets the page call bound functions with JSON-RPC 2.0 requests,
+	// posted with window.chrome.webview.postMessage either as objects or as
+	// JSON strings. Responses are posted back in the same form and can be
+	// received with a message event listener on window.chrome.webview.
+	// By-name params are decoded into the single parameter of the bound
+	// function, so they only work for functions that take exactly one
+	// parameter, besides a leading context.Context; calling other functions
+	// by name fails with JSONRPCInvalidParams. A string that mentions
+	// "jsonrpc" but is not valid JSON is answered with JSONRPCParseError.
+	// The global functions defined by Bind keep working as well.
+	JSONRPC bool
+
+	// Codec encodes the arguments and results of bound functions, including
+	// those called with JSON-RPC. If nil, JSONCodec is used.
+	Codec Codec
+
+	// RecordCalls, if set, receives a line of JSON for every call to a bound
+	// function, with its arguments and its result or error, in the format
+	// read by Replayer. Writes are serialized but not buffered.
+	RecordCalls io.Writer
 }
 
 // New creates a new webview in a new window.
@@ -95,12 +185,41 @@ func NewWindow(debug bool, window unsafe.Pointer) WebView {
 
 // NewWithOptions creates a new webview using the provided options.
 func NewWithOptions(options WebViewOptions) WebView {
-	w := &webview{}
-	w.bindings = map[string]interface{}{}
+	w := newWebview(options, nil)
+	if w == nil {
+		return nil
+	}
+	return w
+}
+
+// newWebview creates a webview, which belongs to app if it is not nil.
+func newWebview(options WebViewOptions, app *App) *webview {
+	w := &webview{app: app}
+	w.bindings = map[string]*binding{}
+	w.calls = map[rpcKey]*rpcCall{}
+	w.timers = map[uintptr]*timer{}
 	w.autofocus = options.AutoFocus
+	w.allowedOrigins = options.AllowedOrigins
+	w.jsonrpc = options.JSONRPC
+	w.codec = options.Codec
+	if w.codec == nil {
+		w.codec = JSONCodec{}
+	}
+	if options.RecordCalls != nil {
+		w.recorder = newRecorder(options.RecordCalls)
+	}
+	workers := options.BindingWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	w.pool = newWorkqueue(workers, nil)
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	w.pagectx, w.pagecancel = context.WithCancel(w.ctx)
 
 	chromium := edge.NewChromium()
-	chromium.MessageCallback = w.msgcb
+	chromium.MessageReceivedCallback = w.messageReceived
+	chromium.ContentLoadingCallback = w.contentLoading
+	chromium.ProcessFailedCallback = w.processFailed
 	chromium.DataPath = options.DataPath
 	chromium.SetPermission(edge.CoreWebView2PermissionKindClipboardRead, edge.CoreWebView2PermissionStateAllow)
 
@@ -109,6 +228,7 @@ func NewWithOptions(options WebViewOptions) WebView {
 	if !w.CreateWithOptions(options.WindowOptions) {
 		return nil
 	}
+	w.Init(originGuard(runtimeScript, w.allowedOrigins))
 
 	settings, err := chromium.GetSettings()
 	if err != nil {
@@ -125,98 +245,296 @@ func NewWithOptions(options WebViewOptions) WebView {
 		log.Fatal(err)
 	}
 
+	// Ready hooks are added after NewWithOptions returns, so they are run
+	// from the message loop.
+	w.Dispatch(w.runReadyHooks)
+
 	return w
 }
 
-type rpcMessage struct {
-	ID     int               `json:"id"`
-	Method string            `json:"method"`
-	Params []json.RawMessage `json:"params"`
+// runtimeScript defines window.go, which lets the page subscribe to events
+// sent with Emit, turns errors from bound functions into Error objects and
+// holds the codec used for arguments and results.
+const runtimeScript = `(function() {
+	var go = window.go = window.go || {};
+	var listeners = {};
+	go.__page = go.__page || Math.random().toString(36).slice(2) + Date.now().toString(36);
+	go.codec = go.codec || {
+	  encode: function(v) { return v; },
+	  decode: function(v) { return v; },
+	};
+	go.__error = function(e) {
+	  var err = new Error(e.message);
+	  if ('code' in e) {
+		err.code = e.code;
+	  }
+	  if ('data' in e) {
+		err.data = e.data;
+	  }
+	  return err;
+	};
+	go.on = function(event, handler) {
+	  (listeners[event] = listeners[event] || []).push(handler);
+	};
+	go.off = function(event, handler) {
+	  if (!handler) {
+		delete listeners[event];
+		return;
+	  }
+	  listeners[event] = (listeners[event] || []).filter(function(h) { return h !== handler; });
+	};
+	var callbacks = {};
+	var nextCallback = 1;
+	go.__callback = function(fn) {
+	  var id = nextCallback++;
+	  callbacks[id] = fn;
+	  return {__jsfunc: id};
+	};
+	go.__call = function(id, args) {
+	  var fn = callbacks[id];
+	  if (!fn) {
+		return;
+	  }
+	  try {
+		fn.apply(null, args.map(function(a) { return go.codec.decode(a); }));
+	  } catch (e) {
+		console.error(e);
+	  }
+	};
+	go.__release = function(id) {
+	  delete callbacks[id];
+	};
+	window.chrome.webview.addEventListener('message', function(e) {
+	  var msg = e.data;
+	  if (!msg || typeof msg !== 'object' || !('__event' in msg)) {
+		return;
+	  }
+	  (listeners[msg.__event] || []).slice().forEach(function(h) { h(msg.payload); });
+	});
+})()`
+
+// evalResultScript wraps js in a try statement, whose completion value is
+// that of js, so that ExecuteScript still returns the result of js. If js
+// throws, the result is instead an object that carries the error along with
+// token, which the page cannot guess. Unlike eval, this is not blocked by a
+// Content-Security-Policy without 'unsafe-eval'.
+func evalResultScript(js, token string) string {
+	return "try {\n" + js + "\n} catch (e) { (" + `function(e, token) {
+	var err = {message: String(e)};
+	if (e instanceof Error) {
+	  err = {name: e.name, message: e.message, stack: e.stack || ''};
+	}
+	if (e && e.code != null) {
+	  err.code = String(e.code);
+	}
+	if (e && e.data !== undefined) {
+	  err.data = e.data;
+	}
+	return {__evalError: token, error: err};
+  }` + ")(e, " + jsString(token) + ") }"
+}
+
+// eventMessage is the web message that Emit posts to the page.
+type eventMessage struct {
+	Event   string      `json:"__event"`
+	Payload interface{} `json:"payload"`
+}
+
+// rpcCall tracks a binding call that has not yet been answered, so that it
+// can be cancelled when the JavaScript caller aborts it.
+type rpcCall struct {
+	cancel context.CancelFunc
+}
+
+// rpcKey identifies a binding call by the document that made it and its ID
+// within that document.
+type rpcKey struct {
+	page string
+	id   int
 }
 
 func jsString(v interface{}) string { b, _ := json.Marshal(v); return string(b) }
 
-func (w *webview) msgcb(msg string) {
-	d := rpcMessage{}
-	if err := json.Unmarshal([]byte(msg), &d); err != nil {
-		log.Printf("invalid RPC message: %v", err)
+func (w *webview) messageReceived(_ *edge.ICoreWebView2, args *edge.ICoreWebView2WebMessageReceivedEventArgs) {
+	source, err := args.GetSource()
+	if err != nil {
+		log.Printf("failed to get web message source: %v", err)
+	}
+
+	msg, err := args.GetWebMessageAsJSON()
+	if err != nil {
+		log.Printf("failed to get web message: %v", err)
 		return
 	}
+	// JSON-RPC requests are recognized first, so that a request that also
+	// happens to decode as a shim message is answered in JSON-RPC form.
+	if w.jsonrpc {
+		payload, asString := []byte(msg), false
+		if s, ok := args.TryGetWebMessageAsString(); ok {
+			payload, asString = []byte(s), true
+		}
+		if isJSONRPC(payload) {
+			w.jsonrpcMessage(payload, source, asString)
+			return
+		}
+	}
 
-	id := strconv.Itoa(d.ID)
-	if res, err := w.callbinding(d); err != nil {
-		w.Dispatch(func() {
-			w.Eval("window._rpc[" + id + "].reject(" + jsString(err.Error()) + "); window._rpc[" + id + "] = undefined")
-		})
-	} else if b, err := json.Marshal(res); err != nil {
-		w.Dispatch(func() {
-			w.Eval("window._rpc[" + id + "].reject(" + jsString(err.Error()) + "); window._rpc[" + id + "] = undefined")
-		})
-	} else {
-		w.Dispatch(func() {
-			w.Eval("window._rpc[" + id + "].resolve(" + string(b) + "); window._rpc[" + id + "] = undefined")
-		})
+	if msg, ok := args.TryGetWebMessageAsString(); ok {
+		d := rpcMessage{Source: source}
+		if err := json.Unmarshal([]byte(msg), &d); err == nil && d.RPC {
+			w.msgcb(d)
+			return
+		}
 	}
-}
 
-func (w *webview) callbinding(d rpcMessage) (interface{}, error) {
 	w.m.Lock()
-	f, ok := w.bindings[d.Method]
+	handlers := append([]func(string, json.RawMessage){}, w.msghandlers...)
 	w.m.Unlock()
-	if !ok {
-		return nil, nil
-	}
-
-	v := reflect.ValueOf(f)
-	isVariadic := v.Type().IsVariadic()
-	numIn := v.Type().NumIn()
-	if (isVariadic && len(d.Params) < numIn-1) || (!isVariadic && len(d.Params) != numIn) {
-		return nil, errors.New("function arguments mismatch")
-	}
-	args := []reflect.Value{}
-	for i := range d.Params {
-		var arg reflect.Value
-		if isVariadic && i >= numIn-1 {
-			arg = reflect.New(v.Type().In(numIn - 1).Elem())
-		} else {
-			arg = reflect.New(v.Type().In(i))
+	for _, h := range handlers {
+		h(source, json.RawMessage(msg))
+	}
+}
+
+func (w *webview) msgcb(d rpcMessage) {
+	if d.Abort {
+		if !originAllowed(w.allowedOrigins, originOf(d.Source)) {
+			return
 		}
-		if err := json.Unmarshal(d.Params[i], arg.Interface()); err != nil {
-			return nil, err
+		w.m.Lock()
+		call, ok := w.calls[rpcKey{d.Page, d.ID}]
+		w.m.Unlock()
+		if ok {
+			call.cancel()
 		}
-		args = append(args, arg.Elem())
+		return
 	}
 
-	errorType := reflect.TypeOf((*error)(nil)).Elem()
-	res := v.Call(args)
-	switch len(res) {
-	case 0:
-		// No results from the function, just return nil
-		return nil, nil
+	w.invoke(d, true, func(res interface{}, err error) {
+		w.reply(d, res, err)
+	})
+}
 
-	case 1:
-		// One result may be a value, or an error
-		if res[0].Type().Implements(errorType) {
-			if res[0].Interface() != nil {
-				return nil, res[0].Interface().(error)
-			}
-			return nil, nil
-		}
-		return res[0].Interface(), nil
+// invoke calls the function bound as d.Method, as its binding options
+// require, and passes the result to done. If abortable is set, the call can
+// be cancelled by an abort message with the same ID. If the document that
+// made the call has gone by the time the function returns, done is not
+// called at all.
+func (w *webview) invoke(d rpcMessage, abortable bool, done func(res interface{}, err error)) {
+	origin := originOf(d.Source)
+	if !originAllowed(w.allowedOrigins, origin) {
+		done(nil, errors.New("calls from "+origin+" are not allowed"))
+		return
+	}
+
+	w.m.Lock()
+	b, ok := w.bindings[d.Method]
+	w.m.Unlock()
+	if ok && !originAllowed(b.opts.AllowedOrigins, origin) {
+		done(nil, errors.New("calls to "+d.Method+" from "+origin+" are not allowed"))
+		return
+	}
+
+	w.m.Lock()
+	ctx, cancel := context.WithCancel(w.pagectx)
+	page := &jsPage{ctx: w.pagectx, codec: w.codec, eval: func(js string) {
+		w.evalAsync(pageScript(d.Page, js))
+	}}
+	call := &rpcCall{cancel: cancel}
+	if abortable {
+		w.calls[rpcKey{d.Page, d.ID}] = call
+	}
+	w.m.Unlock()
 
-	case 2:
-		// Two results: first one is value, second is error
-		if !res[1].Type().Implements(errorType) {
-			return nil, errors.New("second return value must be an error")
+	run := func() {
+		w.m.Lock()
+		middleware := append([]Middleware{Recover}, w.middleware...)
+		w.m.Unlock()
+		res, err := callbinding(ctx, w.codec, middleware, page, b, d)
+		w.endcall(rpcKey{d.Page, d.ID}, call)
+		if w.recorder != nil {
+			settle(d, res, err, func(res interface{}, err error) {
+				w.recorder.record(w.codec, d, res, err)
+			})
 		}
-		if res[1].Interface() == nil {
-			return res[0].Interface(), nil
+		if page.ctx.Err() != nil {
+			// The page navigated away or the window was destroyed, so
+			// there is nobody left to answer.
+			return
 		}
-		return res[0].Interface(), res[1].Interface().(error)
+		settle(d, res, err, done)
+	}
+	if !ok || b.opts.Mode == BindingUIThread {
+		run()
+		return
+	}
+	b.queue.submit(run)
+}
+
+// evalAsync evaluates js on the UI thread without waiting for it. It may be
+// called from any goroutine.
+func (w *webview) evalAsync(js string) {
+	w.Dispatch(func() {
+		w.browser.Eval(js)
+	})
+}
+
+func (w *webview) endcall(key rpcKey, call *rpcCall) {
+	w.m.Lock()
+	if w.calls[key] == call {
+		delete(w.calls, key)
+	}
+	w.m.Unlock()
+	call.cancel()
+}
 
-	default:
-		return nil, errors.New("unexpected number of return values")
+// reply settles the promise of call d with its result. The script only
+// runs in the document that made the call, so a result that arrives after a
+// navigation is dropped instead of settling an unrelated promise.
+func (w *webview) reply(d rpcMessage, res interface{}, err error) {
+	rpc := "window._rpc[" + strconv.Itoa(d.ID) + "]"
+	var js string
+	if err != nil {
+		js = "if (" + rpc + ") { " + rpc + ".reject(window.go.__error(" + jsString(newRPCError(err)) + ")); " + rpc + " = undefined }"
+	} else if b, err := w.codec.Marshal(res); err != nil {
+		js = "if (" + rpc + ") { " + rpc + ".reject(window.go.__error(" + jsString(newRPCError(err)) + ")); " + rpc + " = undefined }"
+	} else {
+		js = "if (" + rpc + ") { " + rpc + ".resolve(window.go.codec.decode(" + string(b) + ")); " + rpc + " = undefined }"
 	}
+	w.evalAsync(pageScript(d.Page, js))
+}
+
+// pageScript wraps js so that it only runs in the document identified by
+// page, which the runtime script stores as window.go.__page.
+func pageScript(page, js string) string {
+	return "if (window.go && window.go.__page === " + jsString(page) + ") { " + js + " }"
+}
+
+func (w *webview) processFailed(_ *edge.ICoreWebView2, args *edge.ICoreWebView2ProcessFailedEventArgs) {
+	kind, err := args.GetProcessFailedKind()
+	if err != nil {
+		log.Printf("failed to get process failure kind: %v", err)
+		return
+	}
+	if kind != edge.COREWEBVIEW2_PROCESS_FAILED_KIND_BROWSER_PROCESS_EXITED {
+		log.Printf("webview process failed (kind %d)", kind)
+		return
+	}
+	if w.app != nil {
+		w.app.fail(ErrBrowserProcessExited)
+		return
+	}
+	w.m.Lock()
+	w.runerr = ErrBrowserProcessExited
+	w.m.Unlock()
+	w.Terminate()
+}
+
+func (w *webview) contentLoading(_ *edge.ICoreWebView2, _ *edge.ICoreWebView2ContentLoadingEventArgs) {
+	w.m.Lock()
+	w.pagecancel()
+	w.pagectx, w.pagecancel = context.WithCancel(w.ctx)
+	w.calls = map[rpcKey]*rpcCall{}
+	w.m.Unlock()
 }
 
 func wndproc(hwnd, msg, wp, lp uintptr) uintptr {
@@ -237,10 +555,30 @@ func wndproc(hwnd, msg, wp, lp uintptr) uintptr {
 			if w.autofocus {
 				w.browser.Focus()
 			}
+		case w32.WMApp:
+			if w.app != nil {
+				w.app.runDispatched()
+			}
+			w.runDispatched()
+		case w32.WMTimer:
+			w.runTimer(wp)
 		case w32.WMClose:
+			if !w.closeRequested() {
+				break
+			}
+			w.runHooks(&w.closedhooks)
 			_, _, _ = w32.User32DestroyWindow.Call(hwnd)
 		case w32.WMDestroy:
-			w.Terminate()
+			w.stopTimers()
+			w.cancel()
+			w.runHooks(&w.destroyedhooks)
+			w.browser.Close()
+			deleteWindowContext(hwnd)
+			if w.app != nil {
+				w.app.windowDestroyed(w)
+			} else {
+				w.Terminate()
+			}
 		case w32.WMGetMinMaxInfo:
 			lpmmi := (*w32.MinMaxInfo)(unsafe.Pointer(lp))
 			if w.maxsz.X > 0 && w.maxsz.Y > 0 {
@@ -282,15 +620,15 @@ func (w *webview) CreateWithOptions(opts WindowOptions) bool {
 	}
 
 	className, _ := windows.UTF16PtrFromString("webview")
-	wc := w32.WndClassExW{
-		CbSize:        uint32(unsafe.Sizeof(w32.WndClassExW{})),
-		HInstance:     hinstance,
-		LpszClassName: className,
-		HIcon:         windows.Handle(icon),
-		HIconSm:       windows.Handle(icon),
-		LpfnWndProc:   windows.NewCallback(wndproc),
-	}
-	_, _, _ = w32.User32RegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
+	registerClassOnce.Do(func() {
+		wc := w32.WndClassExW{
+			CbSize:        uint32(unsafe.Sizeof(w32.WndClassExW{})),
+			HInstance:     hinstance,
+			LpszClassName: className,
+			LpfnWndProc:   windows.NewCallback(wndproc),
+		}
+		_, _, _ = w32.User32RegisterClassExW.Call(uintptr(unsafe.Pointer(&wc)))
+	})
 
 	windowName, _ := windows.UTF16PtrFromString(opts.Title)
 
@@ -333,6 +671,10 @@ func (w *webview) CreateWithOptions(opts WindowOptions) bool {
 	)
 	setWindowContext(w.hwnd, w)
 
+	// The icon is set for each window, since the class is shared.
+	_, _, _ = w32.User32SendMessageW.Call(w.hwnd, w32.WMSetIcon, w32.IconSmall, icon)
+	_, _, _ = w32.User32SendMessageW.Call(w.hwnd, w32.WMSetIcon, w32.IconBig, icon)
+
 	_, _, _ = w32.User32ShowWindow.Call(w.hwnd, w32.SWShow)
 	_, _, _ = w32.User32UpdateWindow.Call(w.hwnd)
 	_, _, _ = w32.User32SetFocus.Call(w.hwnd)
@@ -345,28 +687,136 @@ func (w *webview) CreateWithOptions(opts WindowOptions) bool {
 }
 
 func (w *webview) Destroy() {
+	w.m.Lock()
+	w.destroying = true
+	w.m.Unlock()
+	_, _, _ = w32.User32PostMessageW.Call(w.hwnd, w32.WMClose, 0, 0)
+}
+
+func (w *webview) Close() {
 	_, _, _ = w32.User32PostMessageW.Call(w.hwnd, w32.WMClose, 0, 0)
 }
 
+func (w *webview) OnReady(f func()) {
+	w.m.Lock()
+	ready := w.ready
+	if !ready {
+		w.readyhooks = append(w.readyhooks, f)
+	}
+	w.m.Unlock()
+	if ready {
+		w.Dispatch(f)
+	}
+}
+
+func (w *webview) OnCloseRequested(f func() bool) {
+	w.m.Lock()
+	w.closehooks = append(w.closehooks, f)
+	w.m.Unlock()
+}
+
+func (w *webview) OnClosed(f func()) {
+	w.m.Lock()
+	w.closedhooks = append(w.closedhooks, f)
+	w.m.Unlock()
+}
+
+func (w *webview) OnDestroyed(f func()) {
+	w.m.Lock()
+	w.destroyedhooks = append(w.destroyedhooks, f)
+	w.m.Unlock()
+}
+
+func (w *webview) runReadyHooks() {
+	w.m.Lock()
+	w.ready = true
+	w.m.Unlock()
+	w.runHooks(&w.readyhooks)
+}
+
+// runHooks calls the hooks in *hooks, which is guarded by w.m.
+func (w *webview) runHooks(hooks *[]func()) {
+	w.m.Lock()
+	fs := append([]func(){}, *hooks...)
+	w.m.Unlock()
+	for _, f := range fs {
+		f()
+	}
+}
+
+// closeRequested asks the close hooks whether the window may close. The
+// first hook to refuse stops the others from being asked.
+func (w *webview) closeRequested() bool {
+	w.m.Lock()
+	destroying := w.destroying
+	hooks := append([]func() bool{}, w.closehooks...)
+	w.m.Unlock()
+	if destroying {
+		return true
+	}
+	for _, f := range hooks {
+		if !f() {
+			return false
+		}
+	}
+	return true
+}
+
 func (w *webview) Run() {
+	_ = w.RunContext(context.Background())
+}
+
+func (w *webview) RunContext(ctx context.Context) error {
+	if err := runMessageLoop(ctx, w.Dispatch, w.runDispatched); err != nil {
+		return err
+	}
+	w.m.Lock()
+	runerr := w.runerr
+	w.runerr = nil
+	w.m.Unlock()
+	if runerr != nil {
+		return runerr
+	}
+	return ctx.Err()
+}
+
+// runMessageLoop runs the message loop of the UI thread until WM_QUIT is
+// received or ctx is done, and only returns an error if the loop fails. post
+// queues a function to run on the UI thread, and dispatch runs the functions
+// queued with a message to the thread rather than to a window.
+func runMessageLoop(ctx context.Context, post func(func()), dispatch func()) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			post(func() {
+				select {
+				case <-stop:
+					// The loop has already stopped; do not quit the next one.
+				default:
+					_, _, _ = w32.User32PostQuitMessage.Call(0)
+				}
+			})
+		case <-stop:
+		}
+	}()
+
 	var msg w32.Msg
 	for {
-		_, _, _ = w32.User32GetMessageW.Call(
+		ret, _, err := w32.User32GetMessageW.Call(
 			uintptr(unsafe.Pointer(&msg)),
 			0,
 			0,
 			0,
 		)
-		if msg.Message == w32.WMApp {
-			w.m.Lock()
-			q := append([]func(){}, w.dispatchq...)
-			w.dispatchq = []func(){}
-			w.m.Unlock()
-			for _, v := range q {
-				v()
-			}
+		if int32(ret) == -1 {
+			return fmt.Errorf("GetMessageW failed: %w", err)
+		}
+		if msg.Message == w32.WMApp && msg.Hwnd == 0 {
+			dispatch()
 		} else if msg.Message == w32.WMQuit {
-			return
+			return nil
 		}
 		r, _, _ := w32.User32GetAncestor.Call(uintptr(msg.Hwnd), w32.GARoot)
 		r, _, _ = w32.User32IsDialogMessage.Call(r, uintptr(unsafe.Pointer(&msg)))
@@ -379,7 +829,11 @@ func (w *webview) Run() {
 }
 
 func (w *webview) Terminate() {
-	_, _, _ = w32.User32PostQuitMessage.Call(0)
+	// WM_QUIT is posted to the queue of the calling thread, which must be
+	// the one running the loop.
+	w.ui(func() {
+		_, _, _ = w32.User32PostQuitMessage.Call(0)
+	})
 }
 
 func (w *webview) Window() unsafe.Pointer {
@@ -387,14 +841,22 @@ func (w *webview) Window() unsafe.Pointer {
 }
 
 func (w *webview) Navigate(url string) {
-	w.browser.Navigate(url)
+	w.ui(func() {
+		w.browser.Navigate(url)
+	})
 }
 
 func (w *webview) SetHtml(html string) {
-	w.browser.NavigateToString(html)
+	w.ui(func() {
+		w.browser.NavigateToString(html)
+	})
 }
 
 func (w *webview) SetTitle(title string) {
+	if !w.onUIThread() {
+		w.Dispatch(func() { w.SetTitle(title) })
+		return
+	}
 	_title, err := windows.UTF16FromString(title)
 	if err != nil {
 		_title, _ = windows.UTF16FromString("")
@@ -403,6 +865,10 @@ func (w *webview) SetTitle(title string) {
 }
 
 func (w *webview) SetSize(width int, height int, hints Hint) {
+	if !w.onUIThread() {
+		w.Dispatch(func() { w.SetSize(width, height, hints) })
+		return
+	}
 	index := w32.GWLStyle
 	style, _, _ := w32.User32GetWindowLongPtrW.Call(w.hwnd, uintptr(index))
 	if hints == HintFixed {
@@ -433,50 +899,416 @@ func (w *webview) SetSize(width int, height int, hints Hint) {
 }
 
 func (w *webview) Init(js string) {
-	w.browser.Init(js)
+	w.ui(func() {
+		w.browser.Init(js)
+	})
 }
 
 func (w *webview) Eval(js string) {
-	w.browser.Eval(js)
+	w.ui(func() {
+		w.browser.Eval(js)
+	})
+}
+
+func (w *webview) EvalResult(ctx context.Context, js string) (json.RawMessage, error) {
+	if w.onUIThread() {
+		// The result is delivered on the UI thread, so waiting for it here
+		// would never return.
+		return nil, errors.New("EvalResult must not be called from the UI thread")
+	}
+
+	type result struct {
+		json string
+		err  error
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b[:])
+
+	done := make(chan result, 1)
+	w.Dispatch(func() {
+		w.browser.ExecuteScript(evalResultScript(js, token), func(res string, err error) {
+			done <- result{res, err}
+		})
+	})
+
+	var r result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-w.ctx.Done():
+		return nil, ErrDestroyed
+	case r = <-done:
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var thrown struct {
+		Token string       `json:"__evalError"`
+		Error *ScriptError `json:"error"`
+	}
+	if json.Unmarshal([]byte(r.json), &thrown) == nil && thrown.Token == token && thrown.Error != nil {
+		return nil, thrown.Error
+	}
+	return json.RawMessage(r.json), nil
+}
+
+func (w *webview) onUIThread() bool {
+	thread, _, _ := w32.Kernel32GetCurrentThreadID.Call()
+	return thread == w.mainthread
+}
+
+// ui runs f on the UI thread: right away when called from it, and otherwise
+// through the dispatch queue, without waiting for it to run.
+func (w *webview) ui(f func()) {
+	if w.onUIThread() {
+		f()
+		return
+	}
+	w.Dispatch(f)
+}
+
+func (w *webview) OnMessage(handler func(source string, msg json.RawMessage)) {
+	w.m.Lock()
+	w.msghandlers = append(w.msghandlers, handler)
+	w.m.Unlock()
+}
+
+func (w *webview) PostMessageJSON(msg interface{}) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	w.ui(func() {
+		w.browser.PostWebMessageAsJSON(string(b))
+	})
+	return nil
+}
+
+func (w *webview) PostMessageString(msg string) {
+	w.ui(func() {
+		w.browser.PostWebMessageAsString(msg)
+	})
+}
+
+func (w *webview) Emit(event string, payload interface{}) error {
+	b, err := json.Marshal(eventMessage{Event: event, Payload: payload})
+	if err != nil {
+		return err
+	}
+	w.ui(func() {
+		w.browser.PostWebMessageAsJSON(string(b))
+	})
+	return nil
 }
 
 func (w *webview) Dispatch(f func()) {
 	w.m.Lock()
 	w.dispatchq = append(w.dispatchq, f)
 	w.m.Unlock()
+
+	// A message for the window is delivered to wndproc even by the modal
+	// loops that Windows runs while the window is moved or resized or a
+	// dialog is open, which drop thread messages. Before the window exists,
+	// or after it is gone, the message goes to the thread instead.
+	if w.hwnd != 0 {
+		if r, _, _ := w32.User32PostMessageW.Call(w.hwnd, w32.WMApp, 0, 0); r != 0 {
+			return
+		}
+	}
 	_, _, _ = w32.User32PostThreadMessageW.Call(w.mainthread, w32.WMApp, 0, 0)
 }
 
+// runDispatched runs the functions queued by Dispatch.
+func (w *webview) runDispatched() {
+	w.m.Lock()
+	q := append([]func(){}, w.dispatchq...)
+	w.dispatchq = []func(){}
+	w.m.Unlock()
+	for _, v := range q {
+		v()
+	}
+}
+
+func (w *webview) DispatchSync(f func() (interface{}, error)) (interface{}, error) {
+	if w.onUIThread() {
+		return f()
+	}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan result, 1)
+	w.Dispatch(func() {
+		v, err := f()
+		done <- result{v, err}
+	})
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-w.ctx.Done():
+		return nil, ErrDestroyed
+	}
+}
+
+func (w *webview) DispatchAfter(d time.Duration, f func()) (cancel func()) {
+	return w.startTimer(d, f, false)
+}
+
+func (w *webview) DispatchEvery(d time.Duration, f func()) (cancel func()) {
+	return w.startTimer(d, f, true)
+}
+
+// timer is a Win32 timer of the window started by DispatchAfter or
+// DispatchEvery.
+type timer struct {
+	f      func()
+	repeat bool
+}
+
+func (w *webview) startTimer(d time.Duration, f func(), repeat bool) func() {
+	t := &timer{f: f, repeat: repeat}
+	w.m.Lock()
+	w.nexttimer++
+	id := w.nexttimer
+	w.timers[id] = t
+	w.m.Unlock()
+
+	ms := d.Milliseconds()
+	if ms < 0 {
+		ms = 0
+	}
+	w.ui(func() {
+		w.m.Lock()
+		_, ok := w.timers[id]
+		w.m.Unlock()
+		if ok {
+			_, _, _ = w32.User32SetTimer.Call(w.hwnd, id, uintptr(ms), 0)
+		}
+	})
+
+	return func() {
+		w.m.Lock()
+		_, ok := w.timers[id]
+		delete(w.timers, id)
+		w.m.Unlock()
+		if ok {
+			w.ui(func() {
+				_, _, _ = w32.User32KillTimer.Call(w.hwnd, id)
+			})
+		}
+	}
+}
+
+func (w *webview) runTimer(id uintptr) {
+	w.m.Lock()
+	t, ok := w.timers[id]
+	if ok && !t.repeat {
+		delete(w.timers, id)
+	}
+	w.m.Unlock()
+	if !ok {
+		// The timer was cancelled after it last fired.
+		_, _, _ = w32.User32KillTimer.Call(w.hwnd, id)
+		return
+	}
+	if !t.repeat {
+		_, _, _ = w32.User32KillTimer.Call(w.hwnd, id)
+	}
+	t.f()
+}
+
+// stopTimers stops all timers when the window is destroyed.
+func (w *webview) stopTimers() {
+	w.m.Lock()
+	timers := w.timers
+	w.timers = map[uintptr]*timer{}
+	w.m.Unlock()
+	for id := range timers {
+		_, _, _ = w32.User32KillTimer.Call(w.hwnd, id)
+	}
+}
+
 func (w *webview) Bind(name string, f interface{}) error {
-	v := reflect.ValueOf(f)
-	if v.Kind() != reflect.Func {
-		return errors.New("only functions can be bound")
+	return w.BindWithOptions(name, f, BindOptions{})
+}
+
+func (w *webview) BindWithOptions(name string, f interface{}, opts BindOptions) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if err := checkFunc(f); err != nil {
+		return err
+	}
+	if err := checkOptions(opts); err != nil {
+		return err
+	}
+	b := newBinding(f, opts, w.pool)
+	w.m.Lock()
+	old := w.bindings[name]
+	w.bindings[name] = b
+	w.m.Unlock()
+
+	// Rebinding a name replaces the old shim instead of adding another one.
+	if old != nil {
+		w.removeBindingScript(old)
+	}
+	manifest := newBindingManifest(newBindingInfo(name, reflect.TypeOf(b.f), opts))
+	w.ui(func() {
+		w.browser.AddScriptToExecuteOnDocumentCreated(originGuard(bindingScript(name, manifest), w.allowedOrigins, opts.AllowedOrigins), func(id string, err error) {
+			if err != nil {
+				log.Printf("failed to add binding %s: %v", name, err)
+				return
+			}
+			w.m.Lock()
+			b.script = id
+			removed := b.removed
+			w.m.Unlock()
+			if removed {
+				w.browser.RemoveScriptToExecuteOnDocumentCreated(id)
+			}
+		})
+	})
+
+	return nil
+}
+
+func (w *webview) Unbind(name string) error {
+	w.m.Lock()
+	b, ok := w.bindings[name]
+	delete(w.bindings, name)
+	w.m.Unlock()
+	if !ok {
+		return errors.New("no binding named " + name)
 	}
-	if n := v.Type().NumOut(); n > 2 {
-		return errors.New("function may only return a value or a value+error")
+
+	w.removeBindingScript(b)
+	w.Eval("(function(path) { var target = window;" + `
+		for (var i = 0; i < path.length - 1 && target; i++) {
+		  target = target[path[i]];
+		}
+		if (target) {
+		  delete target[path[path.length - 1]];
+		}
+		if (window.go && window.go.__bindings) {
+		  delete window.go.__bindings[path.join('.')];
+		}
+	})(` + jsString(strings.Split(name, ".")) + ")")
+	return nil
+}
+
+func (w *webview) Use(middleware ...Middleware) {
+	w.m.Lock()
+	w.middleware = append(w.middleware, middleware...)
+	w.m.Unlock()
+}
+
+func (w *webview) BindObject(namespace string, obj interface{}) error {
+	return w.BindObjectWithOptions(namespace, obj, BindOptions{})
+}
+
+func (w *webview) BindObjectWithOptions(namespace string, obj interface{}, opts BindOptions) error {
+	if err := checkName(namespace); err != nil {
+		return err
+	}
+	methods, err := objectMethods(obj, opts.NameCase)
+	if err != nil {
+		return err
+	}
+	for name, f := range methods {
+		if err := w.BindWithOptions(namespace+"."+name, f, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *webview) Bindings() []BindingInfo {
+	w.m.Lock()
+	bindings := make([]BindingInfo, 0, len(w.bindings))
+	for name, b := range w.bindings {
+		bindings = append(bindings, newBindingInfo(name, reflect.TypeOf(b.f), b.opts))
 	}
+	w.m.Unlock()
+	sort.Slice(bindings, func(i, j int) bool { return bindings[i].Name < bindings[j].Name })
+	return bindings
+}
+
+func (w *webview) WriteTypeScript(out io.Writer) error {
 	w.m.Lock()
-	w.bindings[name] = f
+	bindings := make(map[string]interface{}, len(w.bindings))
+	for name, b := range w.bindings {
+		bindings[name] = b.f
+	}
 	w.m.Unlock()
+	return WriteTypeScript(out, bindings)
+}
 
-	w.Init("(function() { var name = " + jsString(name) + ";" + `
+// removeBindingScript removes the shim for b from future documents. If the
+// shim is still being added, it is removed once its ID is known.
+func (w *webview) removeBindingScript(b *binding) {
+	w.m.Lock()
+	b.removed = true
+	id := b.script
+	w.m.Unlock()
+	if id != "" {
+		w.ui(func() {
+			w.browser.RemoveScriptToExecuteOnDocumentCreated(id)
+		})
+	}
+}
+
+func bindingScript(name string, manifest bindingManifest) string {
+	return "(function() { var name = " + jsString(name) + "; var manifest = " + jsString(manifest) + ";" + `
 		var RPC = window._rpc = (window._rpc || {nextSeq: 1});
-		window[name] = function() {
+		var go = window.go = window.go || {};
+		(go.__bindings = go.__bindings || {})[name] = manifest;
+		var path = name.split('.');
+		var target = window;
+		for (var i = 0; i < path.length - 1; i++) {
+		  target = target[path[i]] = target[path[i]] || {};
+		}
+		target[path[path.length - 1]] = function() {
 		  var seq = RPC.nextSeq++;
+		  var params = Array.prototype.slice.call(arguments);
+		  var signal = null;
+		  if (typeof AbortSignal !== 'undefined' && params[params.length - 1] instanceof AbortSignal) {
+			signal = params.pop();
+		  }
 		  var promise = new Promise(function(resolve, reject) {
+			if (signal && signal.aborted) {
+			  reject(signal.reason || new DOMException('Aborted', 'AbortError'));
+			  return;
+			}
 			RPC[seq] = {
 			  resolve: resolve,
 			  reject: reject,
 			};
+			window.external.invoke(JSON.stringify({
+			  __rpc: true,
+			  id: seq,
+			  page: go.__page,
+			  method: name,
+			  params: params.map(function(p) {
+				return typeof p === 'function' ? window.go.__callback(p) : window.go.codec.encode(p);
+			  }),
+			}));
+			if (signal) {
+			  signal.addEventListener('abort', function() {
+				if (!RPC[seq]) {
+				  return;
+				}
+				RPC[seq] = undefined;
+				window.external.invoke(JSON.stringify({__rpc: true, id: seq, page: go.__page, abort: true}));
+				reject(signal.reason || new DOMException('Aborted', 'AbortError'));
+			  });
+			}
 		  });
-		  window.external.invoke(JSON.stringify({
-			id: seq,
-			method: name,
-			params: Array.prototype.slice.call(arguments),
-		  }));
 		  return promise;
 		}
-	})()`)
-
-	return nil
+	})()`
 }
@@ -0,0 +1,59 @@
+package webview2
+
+import "sync"
+
+// workqueue runs functions on their own goroutines, with at most limit of them
+// running at once. Functions that can not start immediately are started in
+// the order they were submitted. A limit of zero means no limit.
+//
+// A workqueue with a parent submits its functions to the parent instead of
+// starting goroutines itself, so a per-binding queue can be layered on top of
+// the shared worker pool.
+type workqueue struct {
+	m       sync.Mutex
+	limit   int
+	active  int
+	pending []func()
+	parent  *workqueue
+}
+
+func newWorkqueue(limit int, parent *workqueue) *workqueue {
+	return &workqueue{limit: limit, parent: parent}
+}
+
+func (q *workqueue) submit(f func()) {
+	q.m.Lock()
+	if q.limit > 0 && q.active >= q.limit {
+		q.pending = append(q.pending, f)
+		q.m.Unlock()
+		return
+	}
+	q.active++
+	q.m.Unlock()
+	q.start(f)
+}
+
+func (q *workqueue) start(f func()) {
+	run := func() {
+		defer q.done()
+		f()
+	}
+	if q.parent != nil {
+		q.parent.submit(run)
+	} else {
+		go run()
+	}
+}
+
+func (q *workqueue) done() {
+	q.m.Lock()
+	if len(q.pending) > 0 {
+		f := q.pending[0]
+		q.pending = q.pending[1:]
+		q.m.Unlock()
+		q.start(f)
+		return
+	}
+	q.active--
+	q.m.Unlock()
+}